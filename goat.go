@@ -17,15 +17,21 @@
 // It is a basic example of terminal emulation with the "goat/term" package.
 // It reads chunks in and writes them to standard output.  Try typing a line
 // and then hitting the up key on the next line.  Try editing a previous line
-// and hitting the up key again.
+// and hitting the up key again.  Start typing a previous line and hit the
+// right key to accept the suggestion.
 //
-// Press ^C, ^D, or type "quit" to exit.
+// Press ^C, ^D, or type "quit" to exit.  Press ^Z to stop it, like any other
+// program.
+//
+// If standard input is not a terminal, goat just reads lines from it, unless
+// the -tty flag is given, in which case it uses the controlling terminal.
 //
 // If something happens and you can't exit, try "killall goat" from another
 // terminal; this shouldn't happen, but it's possible.
 package main
 
 import (
+	"bufio"
 	"flag"
 	"io"
 	"log"
@@ -35,31 +41,81 @@ import (
 	"github.com/kylelemons/goat/termios"
 )
 
-var frame = flag.Bool("frame", false, "Do a frame demo instead of line editing")
+var (
+	frame  = flag.Bool("frame", false, "Do a frame demo instead of line editing")
+	useTTY = flag.Bool("tty", false, "Use the controlling terminal even if standard input is redirected")
+)
 
 func main() {
 	flag.Parse()
 
-	tio, err := termios.NewTermSettings(0)
+	console, fd := os.Stdin, 0
+	if *useTTY {
+		tty, err := termios.OpenControlling()
+		if err != nil {
+			log.Fatalf("tty: %s", err)
+		}
+		defer tty.Close()
+		console, fd = tty, int(tty.Fd())
+	}
+
+	if !termios.IsTerminal(fd) {
+		plainDemo(console)
+		return
+	}
+
+	tio, err := termios.NewTermSettings(fd)
 	if err != nil {
 		log.Fatalf("terminal: %s", err)
 	}
+	guard := tio.Guard()
+	defer guard.Close()
 	if err := tio.Raw(); err != nil {
-		log.Fatalf("rawterm: %s", err)
+		log.Printf("rawterm: %s", err)
+		guard.Exit(1)
 	}
-	defer tio.Reset()
 
 	if *frame {
-		frameDemo(tio)
+		frameDemo(console, tio)
 	} else {
-		lineDemo()
+		lineDemo(console, tio)
 	}
 }
 
-func lineDemo() {
-	tty := term.NewTTY(os.Stdin)
+func plainDemo(console io.Reader) {
+	lines := bufio.NewScanner(console)
+	for lines.Scan() {
+		if lines.Text() == "quit" {
+			break
+		}
+		log.Printf("read: %q", lines.Text())
+	}
+	if err := lines.Err(); err != nil {
+		log.Printf("read: %s", err)
+	}
+}
+
+func lineDemo(console *os.File, tio *termios.TermSettings) {
+	tty := term.NewTTY(console)
+	tty.SetTerminal(tio)
+	tty.SetJobControl(true)
+	tty.SetAutosuggest(true)
+
+	// Use the special characters from the user's terminal settings
+	tty.SetDiscipline(term.DisciplineFrom(tio))
+
+	// Use the key bindings from ~/.inputrc
+	rc, err := term.LoadInputrc("goat")
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("inputrc: %s", err)
+	}
+	rc.Apply(tty)
+
+	// Rewrap the line when the terminal is resized
+	defer tio.WatchSize(tty.Resize)()
 
 	// Prompt after each newline
+	tty.SetPrompt("> ")
 	prompt := func() {
 		io.WriteString(tty, "> ")
 	}
@@ -81,7 +137,7 @@ func lineDemo() {
 		switch str := string(linebuf[:n]); str {
 		case "quit", term.Interrupt, term.EndOfFile:
 			// Quit on "quit", ^C, and ^D
-			io.WriteString(os.Stdout, "Goodbye!\r\n")
+			io.WriteString(console, "Goodbye!\r\n")
 			return
 		case term.CarriageReturn, term.NewLine:
 			// Print out lines
@@ -95,18 +151,30 @@ func lineDemo() {
 	}
 }
 
-func frameDemo(tio *termios.TermSettings) {
-	// Allocate a TTY connected to standard input
-	tty, region := term.NewFrameTTY(os.Stdin)
+func frameDemo(console *os.File, tio *termios.TermSettings) {
+	// Allocate a TTY connected to the console
+	tty, region := term.NewFrameTTY(console)
 	tty.Clear()
 	region.SetBorder(term.SimpleBorder)
 
 	width, height, err := tio.GetSize()
 	if err == nil && width > 0 && height > 0 {
+		tty.SetSize(width, height)
 		region.SetSize(width, height)
 	}
 
+	// Fill the screen when it is resized
+	region.OnResize(func(w, h int) {
+		region.SetSize(w, h)
+	})
+	defer tio.WatchSize(tty.Resize)()
+
 	region.Draw()
+	title := func() {
+		region.Print(0, 0, "goat", term.Style{Attr: term.Bold})
+		tty.Flush()
+	}
+	title()
 
 	// Allocate the line buffer and accumulator
 	linebuf := make([]byte, 128)
@@ -120,6 +188,12 @@ func frameDemo(tio *termios.TermSettings) {
 		}
 
 		// Examine the chunk
+		if w, h, ok := term.ParseResize(linebuf[:n]); ok {
+			width, height = w, h
+			title()
+			continue
+		}
+
 		switch str := string(linebuf[:n]); str {
 		case "quit", term.Interrupt, term.EndOfFile:
 			// Quit on "quit", ^C, and ^D
@@ -0,0 +1,144 @@
+// +build linux darwin
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pty
+
+import (
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/kylelemons/goat/termios"
+)
+
+// SetPacketMode enables or disables packet mode on the master of a
+// pseudoterminal (TIOCPKT).  In packet mode, each read from the master begins
+// with a status byte, which is zero if the rest of the read is data from the
+// slave, or else describes a change in the state of the slave (and nothing
+// else is read).  Use a PacketReader to separate the two.
+func SetPacketMode(master *os.File, enabled bool) error {
+	var on int32
+	if enabled {
+		on = 1
+	}
+	return ioctl(master, syscall.TIOCPKT, unsafe.Pointer(&on))
+}
+
+// SetExtproc enables or disables external processing (EXTPROC) on the slave
+// of a pseudoterminal, given its master.  With EXTPROC set, the line
+// discipline of the slave leaves the editing of input to the master (as in
+// the telnet LINEMODE option), and in packet mode, the master is told (with
+// StatusIoctl) whenever the slave changes its settings, which it can then read
+// with a termios.TermSettings on the master.
+func SetExtproc(master *os.File, enabled bool) error {
+	tio, err := termios.NewTermSettings(int(master.Fd()))
+	if err != nil {
+		return err
+	}
+	if enabled {
+		tio.Enable(termios.EXTPROC)
+	} else {
+		tio.Disable(termios.EXTPROC)
+	}
+	return tio.Apply()
+}
+
+// A Status is the status byte of a read from a master in packet mode.
+type Status byte
+
+// Status bits
+const (
+	StatusFlushRead  Status = syscall.TIOCPKT_FLUSHREAD  // the slave's input queue was flushed
+	StatusFlushWrite Status = syscall.TIOCPKT_FLUSHWRITE // the slave's output queue was flushed
+	StatusStop       Status = syscall.TIOCPKT_STOP       // output to the slave was stopped (^S)
+	StatusStart      Status = syscall.TIOCPKT_START      // output to the slave was restarted (^Q)
+	StatusNoStop     Status = syscall.TIOCPKT_NOSTOP     // the slave stopped using ^S/^Q flow control
+	StatusDoStop     Status = syscall.TIOCPKT_DOSTOP     // the slave started using ^S/^Q flow control
+	StatusIoctl      Status = syscall.TIOCPKT_IOCTL      // the slave changed its settings (with EXTPROC)
+)
+
+var statusNames = []struct {
+	status Status
+	name   string
+}{
+	{StatusFlushRead, "FLUSHREAD"},
+	{StatusFlushWrite, "FLUSHWRITE"},
+	{StatusStop, "STOP"},
+	{StatusStart, "START"},
+	{StatusNoStop, "NOSTOP"},
+	{StatusDoStop, "DOSTOP"},
+	{StatusIoctl, "IOCTL"},
+}
+
+// String returns the names of the status bits, separated by |.
+func (s Status) String() string {
+	var names []string
+	for _, n := range statusNames {
+		if s&n.status != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// A PacketReader reads the data from the master of a pseudoterminal in packet
+// mode, and reports the status changes separately.
+type PacketReader struct {
+	master io.Reader
+	status func(Status)
+	buf    []byte
+}
+
+// NewPacketReader returns a PacketReader which reads from the given master,
+// which must be in packet mode (see SetPacketMode).  The status function (if
+// it is not nil) is called from Read with each change in the status of the
+// slave.
+func NewPacketReader(master io.Reader, status func(Status)) *PacketReader {
+	return &PacketReader{
+		master: master,
+		status: status,
+	}
+}
+
+// Read reads data written to the slave.  Status changes read from the master
+// are passed to the status function while waiting for data.
+func (p *PacketReader) Read(b []byte) (n int, err error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	if cap(p.buf) < len(b)+1 {
+		p.buf = make([]byte, len(b)+1)
+	}
+	buf := p.buf[:len(b)+1]
+	for {
+		n, err := p.master.Read(buf)
+		if n == 0 {
+			return 0, err
+		}
+		if status := Status(buf[0]); status != syscall.TIOCPKT_DATA {
+			if p.status != nil {
+				p.status(status)
+			}
+			if err != nil {
+				return 0, err
+			}
+			continue
+		}
+		return copy(b, buf[1:n]), err
+	}
+}
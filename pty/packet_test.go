@@ -0,0 +1,89 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pty
+
+import (
+	"io"
+	"testing"
+
+	"github.com/kylelemons/goat/termios"
+)
+
+func TestPacketMode(t *testing.T) {
+	master, slave, err := Open()
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	defer master.Close()
+	defer slave.Close()
+
+	if err := SetPacketMode(master, true); err != nil {
+		t.Fatalf("SetPacketMode: %s", err)
+	}
+	if err := SetExtproc(master, true); err != nil {
+		t.Fatalf("SetExtproc: %s", err)
+	}
+
+	statuses := make(chan Status, 10)
+	reader := NewPacketReader(master, func(s Status) { statuses <- s })
+	data := make(chan string)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := reader.Read(buf)
+			if err != nil {
+				close(data)
+				return
+			}
+			data <- string(buf[:n])
+		}
+	}()
+
+	// The child switches to raw mode
+	tio, err := termios.NewTermSettings(int(slave.Fd()))
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+	if err := tio.Raw(); err != nil {
+		t.Fatalf("Raw: %s", err)
+	}
+	io.WriteString(slave, "hello")
+
+	if got, want := <-data, "hello"; got != want {
+		t.Errorf("Read = %q, want %q", got, want)
+	}
+	var status Status
+	for len(statuses) > 0 {
+		status |= <-statuses
+	}
+	if status&StatusIoctl == 0 {
+		t.Errorf("status = %v, want IOCTL", status)
+	}
+
+	// The master can see the new settings
+	now, err := termios.NewTermSettings(int(master.Fd()))
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+	if now.Has(termios.ICANON) || now.Has(termios.ECHO) {
+		t.Errorf("master sees %v, want raw mode", now)
+	}
+}
+
+func TestStatusString(t *testing.T) {
+	if got, want := (StatusIoctl | StatusFlushRead).String(), "FLUSHREAD|IOCTL"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
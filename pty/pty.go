@@ -0,0 +1,141 @@
+// +build linux darwin
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pty allocates pseudoterminals and starts processes on them.
+//
+// A pseudoterminal is a pair of devices: programs started on the slave see an
+// ordinary terminal, while everything they write to it can be read from the
+// master, and everything written to the master is input to them.  This makes
+// it possible to wrap, record, or test interactive programs:
+//   cmd := exec.Command("bash")
+//   master, err := pty.Start(cmd)
+//   if err != nil { ... }
+//   defer master.Close()
+//   tty := term.NewRawTTY(master)
+//
+// On Linux, reading from the master after every process has closed the slave
+// returns an EIO error rather than io.EOF.
+package pty
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// Open allocates a new pseudoterminal and returns its master and slave.  The
+// slave is not made the controlling terminal of this process.
+func Open() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	name, err := unlock(master)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	slave, err = os.OpenFile(name, os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+// Start starts cmd on a new pseudoterminal and returns its master.  The slave
+// becomes the controlling terminal of cmd, which is started in a new session.
+// Any of cmd.Stdin, cmd.Stdout and cmd.Stderr which are nil are connected to
+// the slave.  The slave is closed in this process once cmd has started.
+func Start(cmd *exec.Cmd) (*os.File, error) {
+	return StartWithSize(cmd, 0, 0)
+}
+
+// StartWithSize is like Start, but sets the size of the pseudoterminal to the
+// given number of columns and rows before cmd is started.  If either is zero,
+// the size is not set.
+func StartWithSize(cmd *exec.Cmd, width, height int) (*os.File, error) {
+	master, slave, err := Open()
+	if err != nil {
+		return nil, err
+	}
+	defer slave.Close()
+
+	if width > 0 && height > 0 {
+		if err := SetSize(master, width, height); err != nil {
+			master.Close()
+			return nil, err
+		}
+	}
+
+	if cmd.Stdin == nil {
+		cmd.Stdin = slave
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = slave
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = slave
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = ctty(cmd, slave)
+
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return nil, err
+	}
+	return master, nil
+}
+
+// ctty returns the descriptor number in the child of the slave: 0, 1 or 2 if
+// it is one of the standard streams, or else its position in cmd.ExtraFiles.
+func ctty(cmd *exec.Cmd, slave *os.File) int {
+	for i, f := range []interface{}{cmd.Stdin, cmd.Stdout, cmd.Stderr} {
+		if f == slave {
+			return i
+		}
+	}
+	cmd.ExtraFiles = append(cmd.ExtraFiles, slave)
+	return 2 + len(cmd.ExtraFiles)
+}
+
+// winsize is the layout of struct winsize used by TIOCSWINSZ.
+type winsize struct {
+	row, col       uint16
+	xpixel, ypixel uint16
+}
+
+// SetSize sets the size of the pseudoterminal (given either its master or its
+// slave) to the given number of columns and rows.  The processes in its
+// foreground process group receive SIGWINCH.
+func SetSize(f *os.File, width, height int) error {
+	ws := winsize{row: uint16(height), col: uint16(width)}
+	return ioctl(f, syscall.TIOCSWINSZ, unsafe.Pointer(&ws))
+}
+
+// ioctl performs the given ioctl on f with a pointer argument.
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
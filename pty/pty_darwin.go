@@ -0,0 +1,41 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pty
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// unlock grants access to (grantpt) and unlocks (unlockpt) the slave of the
+// given master and returns its name (ptsname).
+func unlock(master *os.File) (string, error) {
+	if err := ioctl(master, syscall.TIOCPTYGRANT, nil); err != nil {
+		return "", err
+	}
+	if err := ioctl(master, syscall.TIOCPTYUNLK, nil); err != nil {
+		return "", err
+	}
+	name := make([]byte, 128)
+	if err := ioctl(master, syscall.TIOCPTYGNAME, unsafe.Pointer(&name[0])); err != nil {
+		return "", err
+	}
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	return string(name), nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pty
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// unlock unlocks the slave of the given master (unlockpt) and returns its
+// name (ptsname).  On Linux, there is no need to grant access (grantpt), since
+// the slave is created with the right owner and permissions by devpts.
+func unlock(master *os.File) (string, error) {
+	var lock int32
+	if err := ioctl(master, syscall.TIOCSPTLCK, unsafe.Pointer(&lock)); err != nil {
+		return "", err
+	}
+	var n uint32
+	if err := ioctl(master, syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("/dev/pts/%d", n), nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pty
+
+import (
+	"bufio"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	master, slave, err := Open()
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	defer master.Close()
+	defer slave.Close()
+
+	// The slave starts in canonical mode with echo, so the master sees the
+	// line echoed back and the slave reads it
+	io.WriteString(master, "hello\n")
+	line, err := bufio.NewReader(slave).ReadString('\n')
+	if err != nil || line != "hello\n" {
+		t.Errorf("slave read %q, %v; want %q", line, err, "hello\n")
+	}
+
+	io.WriteString(slave, "world\n")
+	buf := make([]byte, 64)
+	var out string
+	for !strings.Contains(out, "world\r\n") {
+		n, err := master.Read(buf)
+		if err != nil {
+			t.Fatalf("master read: %s (after %q)", err, out)
+		}
+		out += string(buf[:n])
+	}
+	if want := "hello\r\nworld\r\n"; out != want {
+		t.Errorf("master read %q, want %q", out, want)
+	}
+}
+
+// readAll reads from the master until the processes on the slave exit.
+func readAll(t *testing.T, master io.Reader) string {
+	var out strings.Builder
+	buf := make([]byte, 256)
+	for {
+		n, err := master.Read(buf)
+		out.Write(buf[:n])
+		if err != nil {
+			return out.String()
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "stty size; tty; test -t 0 && echo ok")
+	master, err := StartWithSize(cmd, 80, 24)
+	if err != nil {
+		t.Fatalf("Start: %s", err)
+	}
+	defer master.Close()
+
+	out := readAll(t, master)
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("Wait: %s (output %q)", err, out)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\r\n")
+	if len(lines) != 3 {
+		t.Fatalf("output = %q, want 3 lines", out)
+	}
+	if got, want := lines[0], "24 80"; got != want {
+		t.Errorf("stty size = %q, want %q", got, want)
+	}
+	if got := lines[1]; !strings.HasPrefix(got, "/dev/") {
+		t.Errorf("tty = %q, want a device", got)
+	}
+	if got, want := lines[2], "ok"; got != want {
+		t.Errorf("test -t 0 = %q, want %q", got, want)
+	}
+}
@@ -24,19 +24,83 @@
 // The line editing facilities are very basic; you can type, and you can
 // backspace out characters up to the beginning of the line.  Note that for all
 // internal purposes, typing a control character (e.g. ^D or ^C) starts a new
-// line, including for line history below.
+// line, including for line history below.  Control characters and unknown
+// escape sequences (such as PageUp) are not echoed unless SetEchoControl and
+// SetEchoEscapes are used to display them as ^C or ^[[5~.
 //
 // You can also use the arrow keys for editing:
 //   LEFT   Move back one character
 //   RIGHT  Move forward one character
 //   DOWN   Move to the end of the line
+//   END    Move to the end of the line
 //   UP     Restore previous line (see below)
 //
+// If the TTY has a Terminal (see SetTerminal), you can also press C-x C-e to
+// edit the line in your $VISUAL or $EDITOR.  Your own programs can be run on
+// the terminal in the same way with Suspend, and SetJobControl makes ^Z stop
+// the process as it would if the terminal were not in raw mode.
+//
+// Key bindings (Line mode)
+//
+// The keys above are the default bindings of a Keymap, which maps the key
+// sequences sent by the terminal to named editing actions (such as
+// "backward-char" or "kill-line") or to your own functions.  Use SetKeymap to
+// rebind keys, add multi-key bindings like C-x C-e, or remove the defaults.
+// Control characters which are not bound are still emitted by themselves.
+//
+// Key bindings and settings can also be read from the user's readline
+// configuration (~/.inputrc) with LoadInputrc and applied to a TTY.
+//
+// Line discipline (Line mode)
+//
+// Since the terminal is in raw mode, the TTY does not see the erase, kill,
+// interrupt and other special characters the user has configured with stty.
+// SetDiscipline gives the TTY a Discipline which describes them (and the CR
+// and NL translations), either filled in by hand when the console is not a
+// terminal (such as a network connection) or taken from the terminal's
+// *termios.TermSettings with DisciplineFrom.
+//
 // Line history (Line mode)
 //
-// Currently the TTY only has a single-line history.  Pressing the return key
-// will save the current line in that history, and pressing the "up" arrow at
-// any time will restore the previous line.
+// Pressing the return key saves the current line in the history, which holds
+// the last HistoryLength distinct lines.  Pressing the "up" arrow at any time
+// restores the previous line, and the history-search-backward and
+// history-search-forward actions (see Keymap.Bind) step through the lines
+// which begin with the text before the cursor.
+//
+// Suggestions (Line mode)
+//
+// The TTY also remembers the last few lines which were entered.  If
+// suggestions are enabled with SetAutosuggest, the most recent of these which
+// begins with the line being typed is shown dimmed after the cursor, in the
+// style of the fish shell.  Pressing RIGHT or END at the end of the line
+// accepts the suggestion, and pressing Alt-f accepts its next word.
+//
+// Modes
+//
+// The input of a TTY is processed by its Mode: Raw passes it through, Line
+// provides the line editing described above, and Frame provides the same
+// editing along with the screen regions of NewFrameTTY.  Applications can
+// process input in their own way (for a hex-entry or form-field mode, say) by
+// implementing Mode and passing it to SetMode; a Mode receives the input along
+// with a Context through which it echoes and emits chunks.
+//
+// Screen (Frame mode)
+//
+// A Frame TTY keeps a buffer of the cells on the screen, each a rune and a
+// Style.  Regions draw into it (see Region.Print, Set and Fill) and Flush
+// writes only the cells which changed since the last Flush (all of them the
+// first time), moving the cursor relatively where that is shorter, so that a
+// frequently updated screen does not need to be repainted in full.
+// Region.Draw writes its own region directly.
+//
+// Window size
+//
+// Call SetSize with the initial size of the terminal, and Resize when it is
+// resized (termios.TermSettings.WatchSize does this on SIGWINCH).  In Line mode, the line being entered is redrawn so
+// that it wraps at the new width.  In Frame mode, each region can re-layout
+// itself (see Region.OnResize) before the screen is redrawn, and a Resize
+// event is read from the TTY (see ParseResize).
 //
 // Example
 //
@@ -15,6 +15,7 @@
 package term
 
 import (
+	"errors"
 	"io"
 	"sync"
 )
@@ -26,16 +27,16 @@ const (
 	DefaultLineBufferSize  = 32
 	DefaultRawBufferSize   = 256
 	DefaultFrameBufferSize = 8
+	HistoryLength          = 64
 )
 
-type ttyMode int
-
-// The following constants are the modes in which the TTY can be set
-const (
-	Raw   ttyMode = iota // All reads are passed through
-	Line                 // Basic line-editing capabilities are provided
-	Frame                // Basic screen-editing capabilities are provided
-)
+// A Terminal is the terminal device underlying a TTY, such as a
+// *termios.TermSettings.  It is used to temporarily restore the terminal to
+// its original settings while another program uses it.
+type Terminal interface {
+	Reset() error // Restore the original settings
+	Raw() error   // Enter raw mode
+}
 
 // A TTY is a simple interface for reading input from a user over a raw
 // terminal emulation interface.
@@ -46,28 +47,54 @@ type TTY struct {
 	// IO
 	console io.Reader
 	screen  io.Writer
+	term    Terminal
+	prompt  string // Printed before the line when it is redrawn
+	width   int    // The width of the terminal (if known, locked by state and drawing)
+	height  int    // The height of the terminal (if known, locked by state and drawing)
+	jobstop func() // Stops the process when ^Z is typed (if job control is enabled)
+	nojobs  func() // Disables job control (if it is enabled)
 
 	// Synchronization and reading
-	next    chan []byte    // Completed chunks (usually lines)
-	partial []byte         // Store partial reads
-	lock    sync.RWMutex   // Synchronize multiple readers (locks partial)
-	error   error          // The error when the reader closed
-	update  chan chan bool // Take ownership of the IO and Settings data
+	next    chan []byte  // Completed chunks (usually lines)
+	queue   sync.Mutex   // Locks queued
+	queued  [][]byte     // Chunks emitted during Resize, which are read before next
+	wake    chan bool    // Signals Read that a chunk has been queued
+	sending [][]byte     // Chunks to send over next once the input is processed (locked by state)
+	partial []byte       // Store partial reads
+	lock    sync.RWMutex // Synchronize multiple readers (locks partial)
+	error   error        // The error when the reader closed
+	state   sync.Mutex   // Held while processing input (locks IO, Settings and State)
+	reading sync.Mutex   // Held while reading from the console
+	in      *interrupter // Reads from the console (locked by state)
 
 	// Settings
-	mode  ttyMode // The current mode of the TTY
-	bsize int     // Initial line buffer size
+	mode     Mode        // The current mode of the TTY
+	bsize    int         // Initial line buffer size
+	suggest  bool        // Whether to show suggestions from history
+	foldcase bool        // Whether suggestions ignore case
+	echoctl  bool        // Whether to echo emitted control characters
+	echoesc  bool        // Whether to echo unknown escape sequences
+	keymap   *Keymap     // Key bindings (Line mode)
+	disc     *Discipline // Special characters and translations (Line mode)
 
 	// State (Line mode)
-	buffer    []byte // The last read from console
-	output    []byte // The pending line/chunk
-	last      []byte // The last line/chunk (used for prevline)
-	preescape []byte // The contents of output before the escape sequence
-	linepos   int    // >= 0 if doing in-place line editing
+	buffer    []byte   // The last read from console
+	output    []byte   // The pending line/chunk
+	last      []byte   // The last line/chunk (used for prevline)
+	history   [][]byte // The most recent lines, oldest first (used for suggestions)
+	hint      []byte   // The suggestion currently displayed after the line
+	hfound    int      // The index in history of the last history search result
+	hprefix   []byte   // The prefix for which history was last searched
+	preescape []byte   // The contents of output before the escape sequence
+	escaping  bool     // Whether output contains a partial escape sequence
+	chord     string   // Keys read so far of a multi-key binding
+	linepos   int      // >= 0 if doing in-place line editing
 
 	// State (Frame mode)
 	regions []*Region
 	active  int
+	drawing sync.Mutex // Held while drawing (locks cellbuf and the regions' geometry)
+	cellbuf *screen    // The cells drawn by the regions (created when needed)
 }
 
 // NewTTY creates a new TTY for interacting with a user via a limited
@@ -79,12 +106,12 @@ func NewTTY(console io.Reader) *TTY {
 		next:    make(chan []byte, ReadBufferLength),
 		mode:    Line,
 		bsize:   DefaultLineBufferSize,
-		update:  make(chan chan bool),
+		keymap:  DefaultKeymap(),
 	}
 
 	t.screen, _ = console.(io.Writer)
 
-	go t.run()
+	t.start()
 	return t
 }
 
@@ -93,7 +120,7 @@ func NewTTY(console io.Reader) *TTY {
 // interactive echo is enabled.
 //
 // A TTY created with NewFrameTTY has synchronized reads, so further input is
-// not processed until the chunk has been read.  The default read buffer size
+// not read from the console until the chunk has been read.  The default read buffer size
 // for a Frame TTY is much smaller than the others.
 //
 // The default region for a new Frame is an 80x24 region with the initial
@@ -106,10 +133,10 @@ func NewFrameTTY(console io.ReadWriter) (*TTY, *Region) {
 		next:    make(chan []byte),
 		mode:    Frame,
 		bsize:   DefaultFrameBufferSize,
-		update:  make(chan chan bool),
+		keymap:  DefaultKeymap(),
 	}
 
-	go t.run()
+	t.start()
 	r := t.NewRegion(80, 24, 0, 0)
 	return t, r
 }
@@ -120,11 +147,12 @@ func NewRawTTY(console io.Reader) *TTY {
 	t := &TTY{
 		console: console,
 		next:    make(chan []byte, ReadBufferLength),
+		mode:    Raw,
 		bsize:   DefaultRawBufferSize,
-		update:  make(chan chan bool),
+		keymap:  DefaultKeymap(),
 	}
 
-	go t.run()
+	t.start()
 	return t
 }
 
@@ -133,10 +161,27 @@ func NewRawTTY(console io.Reader) *TTY {
 // NewTTY, any write error will disable echo.  Providing nil to SetEcho
 // disables interactive echo.
 func (t *TTY) SetEcho(echo io.Writer) {
-	lock := make(chan bool, 1)
-	t.update <- lock
+	t.state.Lock()
+	defer t.state.Unlock()
 	t.screen = echo
-	lock <- true
+}
+
+// SetTerminal sets the terminal device underlying the TTY.  This is required
+// for features which run other programs on the terminal, such as editing the
+// line in an external editor.
+func (t *TTY) SetTerminal(term Terminal) {
+	t.state.Lock()
+	defer t.state.Unlock()
+	t.term = term
+}
+
+// SetPrompt sets the prompt which precedes the line being entered in Line
+// mode.  The TTY does not print the prompt itself, but it uses the prompt when
+// it needs to redraw the line.
+func (t *TTY) SetPrompt(prompt string) {
+	t.state.Lock()
+	defer t.state.Unlock()
+	t.prompt = prompt
 }
 
 // SetLineBuffer sets the initial line buffer size.  In general, you shouldn't
@@ -144,10 +189,9 @@ func (t *TTY) SetEcho(echo io.Writer) {
 // really long, but if you find that you have lots of really long lines it
 // might help reduce garbage.
 func (t *TTY) SetLineBuffer(size int) {
-	lock := make(chan bool, 1)
-	t.update <- lock
+	t.state.Lock()
+	defer t.state.Unlock()
 	t.bsize = size
-	lock <- true
 }
 
 // SetMode sets the TTY mode.
@@ -162,43 +206,94 @@ func (t *TTY) SetLineBuffer(size int) {
 //
 // Frame: Basic screen-editing is enabled.  Currently the same as Line.
 //
+// Any other Mode can also be provided to process the input in its own way
+// (see Mode).  Providing nil to SetMode sets Raw mode.
+//
 // Switching modes will suspend any state tracking for the old mode.  Switching
 // back will resume with the state where it was before the mode was changed,
 // but this may result in unforseen side effects.  Changing modes does not
 // effect the line buffer size or whether reads are synchronous, as is the case
 // for TTYs created explicitly in a certain mode.  It should not usually be
 // necessary to change modes.
-func (t *TTY) SetMode(mode ttyMode) {
-	lock := make(chan bool, 1)
-	t.update <- lock
+func (t *TTY) SetMode(mode Mode) {
+	if mode == nil {
+		mode = Raw
+	}
+
+	t.state.Lock()
+	defer t.state.Unlock()
 	t.mode = mode
-	lock <- true
 }
 
-// echo echoes the bytes if interactive editing is enabled
+// SetKeymap sets the key bindings used in Line mode.  The TTY keeps its own
+// copy of the keymap, so changes made to it afterward will not take effect
+// until SetKeymap is called again.  Providing nil to SetKeymap removes all
+// bindings, including the defaults.
+func (t *TTY) SetKeymap(keymap *Keymap) {
+	if keymap == nil {
+		keymap = NewKeymap()
+	}
+	keymap = keymap.clone()
+
+	t.state.Lock()
+	defer t.state.Unlock()
+	t.keymap = keymap
+	t.chord = ""
+}
+
+// SetAutosuggest enables or disables inline suggestions in Line mode.
+//
+// When enabled, the most recent line in the history which begins with the
+// line being typed is displayed dimmed after the cursor.  Pressing RIGHT or
+// END at the end of the line accepts the whole suggestion, and pressing Alt-f
+// (ESC f) accepts the next word of it.  Suggestions are only displayed if
+// interactive echo is enabled.
+func (t *TTY) SetAutosuggest(enabled bool) {
+	t.state.Lock()
+	defer t.state.Unlock()
+	t.suggest = enabled
+}
+
+// SetEchoControl enables or disables echoing control characters in Line mode.
+//
+// When enabled, each control character which is emitted by itself (such as ^C
+// or ^D) is echoed in caret notation, as the kernel does for a terminal with
+// ECHOCTL set, so that the user can see that it was typed.  Only the ^X
+// marker is echoed; any newline is up to the reader of the TTY.  Control
+// characters are also echoed if the discipline enables them (see
+// SetDiscipline).
+func (t *TTY) SetEchoControl(enabled bool) {
+	t.state.Lock()
+	defer t.state.Unlock()
+	t.echoctl = enabled
+}
+
+// SetEchoEscapes enables or disables echoing unknown escape sequences in Line
+// mode.
+//
+// Escape sequences which are not bound in the keymap are not normally echoed:
+// those ending with ~ (such as PageUp) are ignored and the others are added to
+// the output.  When enabled, they are also echoed in caret notation (for
+// instance, PageUp is echoed as ^[[5~) so that the user can see that the key
+// was pressed.  The marker is only displayed, it is not part of the line.
+func (t *TTY) SetEchoEscapes(enabled bool) {
+	t.state.Lock()
+	defer t.state.Unlock()
+	t.echoesc = enabled
+}
+
+// echo echoes the bytes if interactive editing is enabled and there are any
 //
 // Side effects:
 // - If there is a write error, interactive editing is disabled
 func (t *TTY) echo(b ...byte) {
-	if t.screen != nil {
+	if t.screen != nil && len(b) > 0 {
 		if _, err := t.screen.Write(b); err != nil {
 			t.screen = nil
 		}
 	}
 }
 
-// yield gives the chance for an update to proceed
-//
-// Side effects:
-// - anything
-func (t *TTY) yield() {
-	select {
-	case done := <-t.update:
-		<-done
-	default:
-	}
-}
-
 // emit sends the contents of t.output over the t.next channel, optionally
 // prefixing it with the preescape if any.  Nothing is done if the length of
 // output (including preescape) is zero.
@@ -206,54 +301,204 @@ func (t *TTY) yield() {
 // Side effects:
 // - t.output refers to a newly allocated zero-length slice (with capacity t.bsize)
 // - t.preescape is nil
-// - the output is written to t.next
+// - t.escaping is false
+// - the output is queued to be sent over t.next (see send)
 func (t *TTY) emit() {
 	if len(t.preescape) > 0 {
 		t.output = append(t.preescape, t.output...)
 		t.preescape = nil
 	}
+	t.escaping = false
 	if len(t.output) > 0 {
-		t.next <- t.output
+		t.send(t.output)
 		t.output = make([]byte, 0, t.bsize)
 		t.linepos = -1
 	}
 }
 
-// run is the primary reading goroutine.  It reads chunks from the console, and processes them
-// or (if not in cooked mode) outputs them directly.  Before each read, it gives the setter
-// methods the opportunity to pause it while they poke at the TTY internals.  This is not
-// necessary for reading, which takes data directly from the next channel.
+// start starts the reading goroutine.
+func (t *TTY) start() {
+	t.in = newInterrupter(t.console)
+	t.wake = make(chan bool, 1)
+	go t.run()
+}
+
+// run is the primary reading goroutine.  It passes each chunk read from the
+// console to the current mode while holding the state lock.
 func (t *TTY) run() {
 	defer close(t.next)
 
+	t.state.Lock()
 	t.buffer = make([]byte, t.bsize)
 	t.output = make([]byte, 0, t.bsize)
 	t.linepos = -1
+	t.hfound = -1
+	t.state.Unlock()
 
 	for {
-		t.yield()
-		n, err := t.console.Read(t.buffer)
+		t.reading.Lock()
+		n, err := t.in.read(t.buffer)
+		t.reading.Unlock()
+
+		t.state.Lock()
+		if timeout, ok := err.(interface{ Timeout() bool }); ok && timeout.Timeout() {
+			// Interrupted by Suspend
+			t.state.Unlock()
+			continue
+		}
 		if err != nil {
 			t.emit()
 			t.error = err
+			if t.in.close != nil {
+				t.in.close()
+			}
+			t.in = &interrupter{}
+			t.flushSends()
 			return
 		}
-		t.yield()
-
-		switch t.mode {
-		case Raw:
-			t.next <- t.buffer[:n]
-		case Line, Frame:
-			// Process each character that was read
-			for _, ch := range t.buffer[:n] {
-				if len(t.output) > 0 && t.output[0] == ESC {
-					t.lineesc(ch)
-				} else {
-					t.linechar(ch)
-				}
-			}
+
+		t.mode.Input(&Context{t: t}, t.buffer[:n])
+		t.flushSends()
+	}
+}
+
+// send queues a chunk to be sent over t.next once the current input has been
+// processed (see flushSends).
+//
+// Side effects:
+// - t.sending has the chunk appended
+func (t *TTY) send(chunk []byte) {
+	t.sending = append(t.sending, chunk)
+}
+
+// flushSends releases the state lock and then sends the queued chunks over
+// t.next, so that the lock is not held while waiting for them to be read.
+func (t *TTY) flushSends() {
+	sending := t.sending
+	t.sending = nil
+	t.state.Unlock()
+
+	for _, chunk := range sending {
+		t.next <- chunk
+	}
+}
+
+// Suspend stops processing input, restores the original terminal settings,
+// and runs fn.  Afterward, the terminal is put back into raw mode, the screen
+// is redrawn, and input processing resumes.  This allows fn to run another
+// interactive program on the terminal.  Suspend requires that the TTY have a
+// Terminal (see SetTerminal).  The first error encountered is returned.
+//
+// In Frame mode, the screen is cleared and everything which has been drawn on
+// it is written again (see Flush).  In Line mode, if a line is being entered,
+// the prompt (see SetPrompt) and the line are redrawn.  Other modes are
+// redrawn if they are Redrawers.
+//
+// Any pending read from the console is interrupted, and fn is not run until
+// it has returned, so that no input is taken from fn.  This is done with read
+// deadlines if the console supports them (as do a net.Conn and an *os.File
+// opened on a pipe), or otherwise by waiting for input before reading if the
+// console has a file descriptor (as does an *os.File opened on a terminal
+// device) on linux and darwin.  For other consoles, the pending read will
+// receive the first input from the terminal, so it will not be available to
+// fn.  Timeouts returned by the console are not treated as errors.
+func (t *TTY) Suspend(fn func() error) (err error) {
+	t.state.Lock()
+	defer t.state.Unlock()
+
+	if t.term == nil {
+		return errNoTerminal
+	}
+	if in := t.in; in.interrupt != nil {
+		if err := in.interrupt(); err != nil {
+			return err
 		}
+		t.reading.Lock()
+		defer t.reading.Unlock()
+		defer func() {
+			if rerr := in.resume(); err == nil {
+				err = rerr
+			}
+		}()
+	}
+	return t.suspend(fn, true)
+}
+
+// errNoTerminal is returned when a Terminal is required but not set.
+var errNoTerminal = errors.New("no Terminal (see SetTerminal)")
+
+// suspend restores the original terminal settings, runs fn, and then puts the
+// terminal back into raw mode.  If redraw is true, the screen is redrawn.
+// The first error encountered is returned.
+//
+// Preconditions:
+// - t.term is not nil
+// - Must be called while processing input (so that nothing is reading)
+// Side effects:
+// - redraw() is called (if requested)
+func (t *TTY) suspend(fn func() error, redraw bool) error {
+	if err := t.term.Reset(); err != nil {
+		return err
+	}
+	err := fn()
+	if rerr := t.term.Raw(); err == nil {
+		err = rerr
+	}
+	if redraw {
+		t.redraw()
+	}
+	return err
+}
+
+// redraw redraws the screen for the current mode, if it is a Redrawer.
+func (t *TTY) redraw() {
+	if r, ok := t.mode.(Redrawer); ok {
+		r.Redraw(&Context{t: t, queue: true})
+	}
+}
+
+// redrawFrame clears the screen and then writes every cell which has been
+// drawn.
+//
+// Side effects:
+// - the front buffer of t.cellbuf is the same as the back buffer
+func (t *TTY) redrawFrame() {
+	t.drawing.Lock()
+	defer t.drawing.Unlock()
+
+	t.echo(ESC, '[', '2', 'J')
+	s := t.cells()
+	for i := range s.front {
+		s.front[i] = blank
+	}
+	t.flush()
+}
+
+// redrawLine echoes the prompt and the current line after returning to the
+// beginning of the row.
+//
+// To echo the line, the following is written:
+//   <CR><prompt><line><erase><backspaces>
+// Where <erase> clears the remainder of the row and <backspaces> return the
+// cursor to its position in the line.
+//
+// Side effects:
+// - t.hint has changed
+func (t *TTY) redrawLine() {
+	if len(t.output) == 0 || t.escaping {
+		return
 	}
+	t.hint = nil
+	overwrite := make([]byte, 0, 1+len(t.prompt)+len(t.output)+3+len(t.output))
+	overwrite = append(overwrite, '\r')
+	overwrite = append(overwrite, t.prompt...)
+	overwrite = append(overwrite, t.output...)
+	overwrite = append(overwrite, ESC, '[', 'K')
+	for i := t.cursor(); i < len(t.output); i++ {
+		overwrite = append(overwrite, '\b')
+	}
+	t.echo(overwrite...)
+	t.showhint()
 }
 
 // Read reads the next line, chunk, control sequence, etc from the console.
@@ -261,10 +506,9 @@ func (t *TTY) Read(b []byte) (n int, err error) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	var ok bool
 	if len(t.partial) == 0 {
-		if t.partial, ok = <-t.next; !ok {
-			return 0, t.error
+		if t.partial, err = t.nextChunk(); err != nil {
+			return 0, err
 		}
 	}
 
@@ -273,6 +517,43 @@ func (t *TTY) Read(b []byte) (n int, err error) {
 	return
 }
 
+// nextChunk waits for the next chunk to be queued (see enqueue) or sent over
+// t.next.  If t.next has been closed, the error of the reader is returned.
+func (t *TTY) nextChunk() ([]byte, error) {
+	for {
+		t.queue.Lock()
+		if len(t.queued) > 0 {
+			chunk := t.queued[0]
+			t.queued = t.queued[1:]
+			t.queue.Unlock()
+			return chunk, nil
+		}
+		t.queue.Unlock()
+
+		select {
+		case chunk, ok := <-t.next:
+			if !ok {
+				return nil, t.error
+			}
+			return chunk, nil
+		case <-t.wake:
+		}
+	}
+}
+
+// enqueue queues a chunk to be read from the TTY without waiting for it to be
+// read, so that it can be emitted by a goroutine other than the reader.
+func (t *TTY) enqueue(chunk []byte) {
+	t.queue.Lock()
+	t.queued = append(t.queued, chunk)
+	t.queue.Unlock()
+
+	select {
+	case t.wake <- true:
+	default:
+	}
+}
+
 // Write writes to the same io.Writer that is handing the interactive echo.  If
 // interactive echo is disabled (either directly or because an echo write
 // failed) Write will return EOF.
@@ -0,0 +1,144 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+// A Discipline describes the special characters and input translations of a
+// terminal line discipline, such as the kernel's when the terminal is not in
+// raw mode.  A special character of zero is disabled.
+//
+// A Discipline can be filled in directly (for instance, when the console is a
+// network connection) or it can be obtained from a *termios.TermSettings with
+// DisciplineFrom.
+type Discipline struct {
+	Erase     byte // Deletes the character before the cursor (VERASE)
+	Kill      byte // Deletes from the beginning of the line to the cursor (VKILL)
+	WordErase byte // Deletes the word before the cursor (VWERASE)
+	EOF       byte // Emits the line and then EndOfFile (VEOF)
+	Interrupt byte // Emits the line and then Interrupt (VINTR)
+	Quit      byte // Emits the line and then Quit (VQUIT)
+	Suspend   byte // Emits the line and then Suspend, or stops the process (VSUSP)
+
+	MapCRtoNL   bool // Translate carriage return to newline on input (ICRNL)
+	MapNLtoCR   bool // Translate newline to carriage return on input (INLCR)
+	IgnoreCR    bool // Ignore carriage return on input (IGNCR)
+	EchoControl bool // Echo control characters as ^X when they are emitted (see SetEchoControl)
+}
+
+// SetDiscipline sets the line discipline used in Line and Frame mode.  The TTY
+// keeps its own copy of the discipline.  Providing nil to SetDiscipline
+// restores the default behavior, in which only the keymap is used.
+//
+// The special characters of the discipline take precedence over the keymap,
+// but they do not remove its bindings; for instance, with the default keymap
+// both BS and DEL delete a character regardless of the Erase character.  The
+// Interrupt, Quit, Suspend and EOF characters are emitted as the constants of
+// the same name (so a DEL configured as Interrupt is read as "\x03"), and the
+// Suspend character stops the process if job control is enabled (see
+// SetJobControl).
+//
+// The CR and NL translations are applied to every input byte before it is
+// processed, as the kernel does before the line discipline sees it.
+func (t *TTY) SetDiscipline(disc *Discipline) {
+	if disc != nil {
+		copied := *disc
+		disc = &copied
+	}
+
+	t.state.Lock()
+	defer t.state.Unlock()
+	t.disc = disc
+}
+
+// translate applies the CR and NL translations of the discipline to an input
+// byte.  If the byte is to be ignored, ok is false.
+func (t *TTY) translate(in byte) (out byte, ok bool) {
+	d := t.disc
+	switch {
+	case d == nil:
+	case in == CR && d.IgnoreCR:
+		return 0, false
+	case in == CR && d.MapCRtoNL:
+		return LF, true
+	case in == LF && d.MapNLtoCR:
+		return CR, true
+	}
+	return in, true
+}
+
+// discipline processes a key if it is one of the special characters of the
+// discipline, and reports whether it was.
+//
+// Side Effects (possible):
+// - t.output points to a new/different slice or has changed
+// - a chunk is queued to be sent over t.next (see send)
+func (t *TTY) discipline(k string) bool {
+	d := t.disc
+	if d == nil || len(k) != 1 || k[0] == NUL {
+		return false
+	}
+	switch ch := k[0]; ch {
+	case d.Erase:
+		t.backwardDeleteChar(k)
+	case d.Kill:
+		t.unixLineDiscard(k)
+	case d.WordErase:
+		t.unixWordRubout(k)
+	case d.Suspend:
+		if t.jobstop != nil && t.term != nil {
+			t.jobstop()
+			break
+		}
+		t.control(ch, Suspend)
+	case d.Interrupt:
+		t.control(ch, Interrupt)
+	case d.Quit:
+		t.control(ch, Quit)
+	case d.EOF:
+		t.control(ch, EndOfFile)
+	default:
+		return false
+	}
+	return true
+}
+
+// control writes the current output and then writes the chunk by itself.  If
+// control characters are echoed (see SetEchoControl), the typed character is
+// echoed first, as ^X if it is a control character.
+//
+// Side effects:
+// - t.output refers to a newly allocated slice (if it wasn't empty)
+// - a chunk is queued to be sent over t.next (see send)
+func (t *TTY) control(typed byte, chunk string) {
+	if t.echoctl || (t.disc != nil && t.disc.EchoControl) {
+		t.echo(caret(typed)...)
+	}
+	t.emit()
+	t.send([]byte(chunk))
+}
+
+// caret returns the bytes with each control character replaced by its caret
+// notation (such as ^C for ETX, ^[ for ESC and ^? for DEL), as the kernel
+// echoes them with ECHOCTL.
+func caret(b ...byte) []byte {
+	out := make([]byte, 0, 2*len(b))
+	for _, ch := range b {
+		if ch < ' ' || ch == DEL {
+			out = append(out, '^', ch^0x40)
+			continue
+		}
+		out = append(out, ch)
+	}
+	return out
+}
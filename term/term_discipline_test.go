@@ -0,0 +1,142 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"io"
+	"testing"
+)
+
+// sane is the discipline of a terminal after "stty sane".
+var sane = Discipline{
+	Erase:       DEL,
+	Kill:        NAK,
+	WordErase:   ETB,
+	EOF:         EOT,
+	Interrupt:   ETX,
+	Quit:        FS,
+	Suspend:     SUB,
+	MapCRtoNL:   true,
+	EchoControl: true,
+}
+
+var disciplineTests = []struct {
+	Desc       string
+	Discipline func(d *Discipline)
+	Chunks     []string
+	Echo       []string
+	Output     []string
+}{
+	{
+		Desc:   "icrnl",
+		Chunks: []string{"ab\r"},
+		Echo:   []string{"a", "b", "\r\n"},
+		Output: []string{"ab", "\n"},
+	},
+	{
+		Desc: "inlcr",
+		Discipline: func(d *Discipline) {
+			d.MapCRtoNL, d.MapNLtoCR = false, true
+		},
+		Chunks: []string{"ab\n"},
+		Output: []string{"ab", "\r"},
+	},
+	{
+		Desc: "igncr",
+		Discipline: func(d *Discipline) {
+			d.IgnoreCR = true
+		},
+		Chunks: []string{"a\rb\n"},
+		Output: []string{"ab", "\n"},
+	},
+	{
+		Desc:   "kill",
+		Chunks: []string{"one\x15two\r"},
+		Output: []string{"two", "\n"},
+	},
+	{
+		Desc:   "werase",
+		Chunks: []string{"one two\x17three\r"},
+		Output: []string{"one three", "\n"},
+	},
+	{
+		Desc: "erase",
+		Discipline: func(d *Discipline) {
+			d.Erase = '#'
+		},
+		Chunks: []string{"abx#c\r"},
+		Output: []string{"abc", "\n"},
+	},
+	{
+		Desc:   "echoctl",
+		Chunks: []string{"ab\x03"},
+		Echo:   []string{"a", "b", "^C"},
+		Output: []string{"ab", Interrupt},
+	},
+	{
+		Desc: "intr DEL",
+		Discipline: func(d *Discipline) {
+			d.Erase, d.Interrupt = BS, DEL
+		},
+		Chunks: []string{"ab\x7f"},
+		Echo:   []string{"a", "b", "^?"},
+		Output: []string{"ab", Interrupt},
+	},
+	{
+		Desc: "quit disabled",
+		Discipline: func(d *Discipline) {
+			d.Quit = 0
+			d.EchoControl = false
+		},
+		Chunks: []string{"ab\x1c\x04"},
+		Echo:   []string{"a", "b"},
+		Output: []string{"ab", "\x1c", EndOfFile},
+	},
+	{
+		Desc:   "susp",
+		Chunks: []string{"\x1a"},
+		Echo:   []string{"^Z"},
+		Output: []string{Suspend},
+	},
+}
+
+func TestDiscipline(t *testing.T) {
+	for _, test := range disciplineTests {
+		desc := test.Desc
+		done := make(chan bool)
+		pipe := NewDoublePipe()
+		tty := NewTTY(pipe.Remote)
+		disc := sane
+		if test.Discipline != nil {
+			test.Discipline(&disc)
+		}
+		tty.SetDiscipline(&disc)
+
+		go VerifyReads(t, desc, "read", tty, test.Output, done)
+		go VerifyReads(t, desc, "echo", pipe.Local, test.Echo, done)
+
+		for _, chunk := range test.Chunks {
+			if _, err := io.WriteString(pipe.Local, chunk); err != nil {
+				t.Errorf("%s: write(%q): %s", desc, chunk, err)
+			}
+		}
+
+		pipe.Local.Close()
+		<-done
+
+		pipe.Remote.Close()
+		<-done
+	}
+}
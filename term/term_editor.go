@@ -0,0 +1,93 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// editor returns the command line for the user's preferred editor.
+func editor() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if cmd := strings.Fields(os.Getenv(env)); len(cmd) > 0 {
+			return cmd
+		}
+	}
+	return []string{"vi"}
+}
+
+// editLine edits the current line in an external editor.  The line is written
+// to a temporary file, the terminal is restored while the editor runs, and
+// then the edited file replaces the current line.  If there is no Terminal or
+// anything goes wrong, the line is left as it was and the bell is rung.
+//
+// Side effects:
+// - replace() is called
+func (t *TTY) editLine(string) {
+	if t.term == nil {
+		t.echo(BEL)
+		return
+	}
+
+	line, err := t.edit(t.output)
+	if err != nil {
+		t.echo(BEL)
+		return
+	}
+	t.replace(line)
+}
+
+// edit runs the user's editor on a temporary file containing line and returns
+// the edited contents of the file (on one line).
+func (t *TTY) edit(line []byte) ([]byte, error) {
+	f, err := ioutil.TempFile("", "goat")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(line)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	args := editor()
+	cmd := exec.Command(args[0], append(args[1:], f.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if in, ok := t.console.(*os.File); ok {
+		cmd.Stdin = in
+	}
+	if out, ok := t.screen.(*os.File); ok {
+		cmd.Stdout, cmd.Stderr = out, out
+	}
+	if err := t.suspend(cmd.Run, false); err != nil {
+		return nil, err
+	}
+
+	edited, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	edited = bytes.TrimRight(edited, "\r\n")
+	edited = bytes.Replace(edited, []byte("\r\n"), []byte{'\n'}, -1)
+	return bytes.Replace(edited, []byte{'\n'}, []byte{' '}, -1), nil
+}
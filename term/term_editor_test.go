@@ -0,0 +1,124 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// FakeTerminal records the calls made to a Terminal.
+type FakeTerminal struct {
+	lock  sync.Mutex
+	calls []string
+}
+
+func (f *FakeTerminal) record(call string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls = append(f.calls, call)
+	return nil
+}
+
+func (f *FakeTerminal) Reset() error { return f.record("Reset") }
+func (f *FakeTerminal) Raw() error   { return f.record("Raw") }
+
+func (f *FakeTerminal) Calls() string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return strings.Join(f.calls, " ")
+}
+
+var editTests = []struct {
+	Desc     string
+	Editor   string
+	Terminal bool
+	Chunks   []string
+	Echo     []string
+	Output   []string
+	Calls    string
+}{
+	{
+		Desc:     "edit",
+		Editor:   "sed -i s/world/there/",
+		Terminal: true,
+		Chunks:   []string{"hello world", "\x18\x05", "!"},
+		Echo:     []string{"h", "e", "l", "l", "o", " ", "w", "o", "r", "l", "d", "\b\b\b\b\b\b\b\b\b\b\bhello there", "!"},
+		Output:   []string{"hello there!"},
+		Calls:    "Reset Raw",
+	},
+	{
+		Desc:     "multiple lines",
+		Editor:   "sed -i s/b/\\n/",
+		Terminal: true,
+		Chunks:   []string{"abc", "\x18\x05"},
+		Echo:     []string{"a", "b", "c", "\b\b\ba c"},
+		Output:   []string{"a c"},
+		Calls:    "Reset Raw",
+	},
+	{
+		Desc:     "editor fails",
+		Editor:   "false",
+		Terminal: true,
+		Chunks:   []string{"abc", "\x18\x05"},
+		Echo:     []string{"a", "b", "c", "\a"},
+		Output:   []string{"abc"},
+		Calls:    "Reset Raw",
+	},
+	{
+		Desc:   "no terminal",
+		Editor: "sed -i s/b/x/",
+		Chunks: []string{"abc", "\x18\x05"},
+		Echo:   []string{"a", "b", "c", "\a"},
+		Output: []string{"abc"},
+	},
+}
+
+func TestEditLine(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	for _, test := range editTests {
+		desc := test.Desc
+		t.Setenv("EDITOR", test.Editor)
+
+		done := make(chan bool)
+		pipe := NewDoublePipe()
+		tty := NewTTY(pipe.Remote)
+		term := new(FakeTerminal)
+		if test.Terminal {
+			tty.SetTerminal(term)
+		}
+
+		go VerifyReads(t, desc, "read", tty, test.Output, done)
+		go VerifyReads(t, desc, "echo", pipe.Local, test.Echo, done)
+
+		for _, chunk := range test.Chunks {
+			if _, err := io.WriteString(pipe.Local, chunk); err != nil {
+				t.Errorf("%s: write(%q): %s", desc, chunk, err)
+			}
+		}
+
+		pipe.Local.Close()
+		<-done
+
+		pipe.Remote.Close()
+		<-done
+
+		if got, want := term.Calls(), test.Calls; got != want {
+			t.Errorf("%s: terminal calls = %q, want %q", desc, got, want)
+		}
+	}
+}
@@ -39,9 +39,13 @@ type Region struct {
 	tty     *TTY
 	content rect
 	border  borderStyle
+	resize  func(width, height int)
 }
 
 func (t *TTY) NewRegion(w, h, x, y int) *Region {
+	t.state.Lock()
+	defer t.state.Unlock()
+
 	if t.screen == nil {
 		return nil
 	}
@@ -50,13 +54,27 @@ func (t *TTY) NewRegion(w, h, x, y int) *Region {
 		return nil
 	}
 
-	return &Region{
+	r := &Region{
 		tty:     t,
 		content: rect{x, y, w, h},
 	}
+	t.regions = append(t.regions, r)
+	return r
+}
+
+// OnResize sets a function which is called with the new size of the screen
+// when the TTY is resized (see Resize), before the regions are redrawn.  It
+// can be used to change the size and position of the region.
+func (r *Region) OnResize(fn func(width, height int)) {
+	r.tty.state.Lock()
+	defer r.tty.state.Unlock()
+	r.resize = fn
 }
 
 func (r *Region) SetBorder(style borderStyle) {
+	r.tty.drawing.Lock()
+	defer r.tty.drawing.Unlock()
+
 	if r.border == nil {
 		r.content = r.content.grow(-1, -1)
 	}
@@ -67,6 +85,9 @@ func (r *Region) SetBorder(style borderStyle) {
 }
 
 func (r *Region) SetPos(x, y int) {
+	r.tty.drawing.Lock()
+	defer r.tty.drawing.Unlock()
+
 	if r.border != nil {
 		x, y = x+1, y+1
 	}
@@ -74,6 +95,9 @@ func (r *Region) SetPos(x, y int) {
 }
 
 func (r *Region) SetSize(width, height int) {
+	r.tty.drawing.Lock()
+	defer r.tty.drawing.Unlock()
+
 	if r.border != nil {
 		width, height = width-2, height-2
 	}
@@ -86,56 +110,145 @@ func (r *Region) SetSize(width, height int) {
 	r.content.width, r.content.height = width, height
 }
 
+// Draw draws the region (its border, if any, and blank contents) and places
+// the cursor at the top left of the contents.  Only the region is written to
+// the screen; other changes are left for Flush.
+//
+// Characters of the border above 127 are taken to be from code page 437 (as
+// are those of FancyBorder) and drawn as the equivalent Unicode characters.
 func (r *Region) Draw() {
-	rect := r.content
-	border := r.border != nil
-	if border {
-		rect = rect.grow(1, 1)
+	t := r.tty
+	t.drawing.Lock()
+	defer t.drawing.Unlock()
+
+	r.paint()
+	s := t.cells()
+	for _, out := range s.rows(r.bounds()) {
+		t.echo(out...)
 	}
+	s.curx, s.cury = r.content.x, r.content.y
+	t.echo(move(nil, -1, -1, s.curx, s.cury)...)
+}
 
-	line := make([]byte, rect.width)
-	start, end := 0, rect.width
-	if border {
-		start, end = 1, rect.width-1
+// bounds returns the rectangle covered by the region, including its border.
+func (r *Region) bounds() rect {
+	if r.border != nil {
+		return r.content.grow(1, 1)
 	}
+	return r.content
+}
+
+// paint draws the border and blank contents of the region into the screen
+// buffer.  The caller must hold t.drawing.
+func (r *Region) paint() {
+	rect := r.bounds()
+	border := r.border != nil
 
+	s := r.tty.cells()
 	for row := 0; row < rect.height; row++ {
-		if row < 2 || row == rect.height-1 {
-			fill := byte(' ')
+		for col := 0; col < rect.width; col++ {
+			ch := byte(' ')
 			if border {
-				switch row {
-				case 0:
-					fill = r.border[borderHorizontal]
-					line[0] = r.border[borderTopLeft]
-					line[len(line)-1] = r.border[borderTopRight]
-				default:
-					line[0] = r.border[borderVertical]
-					line[len(line)-1] = r.border[borderVertical]
-				case rect.height - 1:
-					fill = r.border[borderHorizontal]
-					line[0] = r.border[borderBottomLeft]
-					line[len(line)-1] = r.border[borderBottomRight]
-				}
-			}
-			for col := start; col < end; col++ {
-				line[col] = fill
+				ch = r.borderAt(col, row, rect)
 			}
+			s.set(rect.x+col, rect.y+row, Cell{boxRune(ch), Style{}})
 		}
-		r.tty.SetCursor(rect.x, rect.y+row)
-		r.tty.echo(line...)
 	}
+}
+
+// borderAt returns the border character at col,row (relative to the border).
+func (r *Region) borderAt(col, row int, rect rect) byte {
+	first, last := col == 0, col == rect.width-1
+	switch row {
+	case 0:
+		switch {
+		case first:
+			return r.border[borderTopLeft]
+		case last:
+			return r.border[borderTopRight]
+		}
+		return r.border[borderHorizontal]
+	case rect.height - 1:
+		switch {
+		case first:
+			return r.border[borderBottomLeft]
+		case last:
+			return r.border[borderBottomRight]
+		}
+		return r.border[borderHorizontal]
+	}
+	if first || last {
+		return r.border[borderVertical]
+	}
+	return ' '
+}
 
-	r.tty.SetCursor(r.content.x, r.content.y)
+// Set sets the cell at x,y within the contents of the region.  Nothing is
+// drawn outside the contents.  The change is not displayed until Flush.
+func (r *Region) Set(x, y int, c Cell) {
+	r.tty.drawing.Lock()
+	defer r.tty.drawing.Unlock()
+	r.set(x, y, c)
 }
 
+// set is Set, for a caller which holds t.drawing.
+func (r *Region) set(x, y int, c Cell) {
+	if x < 0 || y < 0 || x >= r.content.width || y >= r.content.height {
+		return
+	}
+	r.tty.cells().set(r.content.x+x, r.content.y+y, c)
+}
+
+// Print draws the text starting at x,y within the contents of the region, a
+// column per rune, and returns the column after it.  The text is clipped to
+// the contents.  The change is not displayed until Flush.
+func (r *Region) Print(x, y int, text string, style Style) int {
+	r.tty.drawing.Lock()
+	defer r.tty.drawing.Unlock()
+
+	for _, ch := range text {
+		r.set(x, y, Cell{ch, style})
+		x++
+	}
+	return x
+}
+
+// Fill sets every cell of the contents of the region.  The change is not
+// displayed until Flush.
+func (r *Region) Fill(c Cell) {
+	r.tty.drawing.Lock()
+	defer r.tty.drawing.Unlock()
+
+	for y := 0; y < r.content.height; y++ {
+		for x := 0; x < r.content.width; x++ {
+			r.set(x, y, c)
+		}
+	}
+}
+
+// Clear clears the screen and everything which has been drawn on it.
 func (t *TTY) Clear() {
+	t.drawing.Lock()
+	defer t.drawing.Unlock()
+	t.clear()
+}
+
+// clear is Clear, for a caller which holds t.drawing.
+func (t *TTY) clear() {
 	t.echo('\x1b', '[', '2', 'J')
+	t.cells().clear()
 }
 
-// SetCursor Places the cursor at the given x,y position.
+// SetCursor Places the cursor at the given x,y position.  It is also where
+// the cursor is left by Flush.
 //
 // Both x and y start at 0 and increase right and down.
 func (t *TTY) SetCursor(x, y int) {
+	t.drawing.Lock()
+	defer t.drawing.Unlock()
+
+	s := t.cells()
+	s.curx, s.cury = x, y
 	if t.screen == nil {
 		return
 	}
@@ -158,6 +271,20 @@ var FancyBorder = borderStyle{
 	192, 193, 217, // Bottom: Left, Tee, Right
 }
 
+// cp437 maps the box-drawing characters of code page 437 to Unicode.
+var cp437 = map[byte]rune{
+	179: '│', 180: '┤', 191: '┐', 192: '└', 193: '┴', 194: '┬',
+	195: '├', 196: '─', 197: '┼', 217: '┘', 218: '┌',
+}
+
+// boxRune returns the rune for a border character.
+func boxRune(ch byte) rune {
+	if r, ok := cp437[ch]; ok {
+		return r
+	}
+	return rune(ch)
+}
+
 const (
 	borderHorizontal = iota
 	borderVertical
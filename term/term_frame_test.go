@@ -15,8 +15,10 @@
 package term
 
 import (
+	"fmt"
 	"io"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -113,3 +115,35 @@ func TestFrame(t *testing.T) {
 		<-done
 	}
 }
+
+func TestFrameLarge(t *testing.T) {
+	const width, height = 100, 30
+
+	pipe := NewDoublePipe()
+	tty, region := NewFrameTTY(pipe.Remote)
+	tty.SetSize(width, height)
+	region.SetSize(width, height)
+	region.SetBorder(SimpleBorder)
+
+	// Every row is drawn, although the region is larger than the default
+	var want []string
+	for row := 0; row < height; row++ {
+		line := "|" + strings.Repeat(" ", width-2) + "|"
+		switch row {
+		case 0:
+			line = "," + strings.Repeat("-", width-2) + "."
+		case height - 1:
+			line = "`" + strings.Repeat("-", width-2) + "'"
+		}
+		want = append(want, fmt.Sprintf("\x1b[%d;1H", row+1), line)
+	}
+	want = append(want, "\x1b[2;2H")
+
+	done := make(chan bool)
+	go VerifyReads(t, "large frame", "echo", pipe.Local, want, done)
+	region.Draw()
+
+	pipe.Remote.Close()
+	<-done
+	pipe.Local.Close()
+}
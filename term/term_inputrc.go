@@ -0,0 +1,467 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// An Inputrc holds the configuration read from GNU readline inputrc files,
+// which can be applied to the line editor of a TTY.
+//
+// Key bindings are added to the keymap for the editing mode in which they are
+// made.  Since the line editor is modeless, the emacs bindings are used in
+// emacs mode and the vi-insert bindings are used in vi mode; bindings made in
+// the vi-command keymap are ignored.  Bindings to functions which the line
+// editor does not provide are ignored (see Keymap.Bind for those it does).
+// Macros insert their text at the cursor.
+//
+// All variables which are set are available in Variables.  Of these, only
+// editing-mode and completion-ignore-case (which makes suggestions ignore case)
+// are applied to the TTY.
+type Inputrc struct {
+	App  string // The application name (used for $if)
+	Term string // The terminal name (used for $if term=)
+
+	Variables map[string]string // Variables which were set
+	Ignored   []string          // Lines which were not understood
+
+	keymaps map[string]*Keymap // The emacs and vi-insert keymaps
+	target  string             // The keymap to which bindings are added
+	conds   []bool             // The state of each nested $if
+	loading []string           // The files being loaded (to detect $include cycles)
+}
+
+// MaxIncludeDepth is the greatest number of files which can be loaded at once
+// through nested $include directives.
+const MaxIncludeDepth = 16
+
+// NewInputrc returns an Inputrc with the default bindings and settings.  The
+// terminal name is taken from the TERM environment variable.
+func NewInputrc(app string) *Inputrc {
+	return &Inputrc{
+		App:       app,
+		Term:      os.Getenv("TERM"),
+		Variables: map[string]string{"editing-mode": "emacs"},
+		keymaps: map[string]*Keymap{
+			"emacs":     DefaultKeymap(),
+			"vi-insert": DefaultKeymap(),
+		},
+		target: "emacs",
+	}
+}
+
+// LoadInputrc reads the user's inputrc file, which is named by the INPUTRC
+// environment variable or is ~/.inputrc by default.  If the file does not
+// exist, the returned Inputrc contains the defaults and the error satisfies
+// os.IsNotExist.
+func LoadInputrc(app string) (*Inputrc, error) {
+	rc := NewInputrc(app)
+	path := os.Getenv("INPUTRC")
+	if path == "" {
+		path = "~/.inputrc"
+	}
+	return rc, rc.Load(path)
+}
+
+// Load reads the named inputrc file.  A leading ~/ is replaced with the
+// user's home directory.  An error is returned if the file is already being
+// loaded (it includes itself) or if more than MaxIncludeDepth files would be
+// loaded at once.
+func (rc *Inputrc) Load(path string) error {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	for _, open := range rc.loading {
+		if open == path {
+			return fmt.Errorf("%s: recursive $include", path)
+		}
+	}
+	if len(rc.loading) >= MaxIncludeDepth {
+		return fmt.Errorf("%s: $include nested too deeply", path)
+	}
+	rc.loading = append(rc.loading, path)
+	defer func() { rc.loading = rc.loading[:len(rc.loading)-1] }()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return rc.Parse(f, path)
+}
+
+// Parse reads inputrc directives from r.  The name is used for messages in
+// Ignored.  The only errors returned are those which occur while reading.
+func (rc *Inputrc) Parse(r io.Reader, name string) error {
+	depth := len(rc.conds)
+	defer func() { rc.conds = rc.conds[:depth] }()
+
+	lines := bufio.NewScanner(r)
+	for lineno := 1; lines.Scan(); lineno++ {
+		line := strings.TrimSpace(lines.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		if err := rc.directive(line); err != nil {
+			rc.Ignored = append(rc.Ignored, fmt.Sprintf("%s:%d: %s", name, lineno, err))
+		}
+	}
+	return lines.Err()
+}
+
+// Keymap returns the keymap for the current editing mode.
+func (rc *Inputrc) Keymap() *Keymap {
+	if rc.Variables["editing-mode"] == "vi" {
+		return rc.keymaps["vi-insert"]
+	}
+	return rc.keymaps["emacs"]
+}
+
+// Apply applies the key bindings and settings to the given TTY.
+func (rc *Inputrc) Apply(t *TTY) {
+	t.SetKeymap(rc.Keymap())
+
+	t.state.Lock()
+	defer t.state.Unlock()
+	t.foldcase = rc.Variables["completion-ignore-case"] == "on"
+}
+
+// skipping returns true if directives are currently being skipped because of
+// a false $if condition.
+func (rc *Inputrc) skipping() bool {
+	for _, cond := range rc.conds {
+		if !cond {
+			return true
+		}
+	}
+	return false
+}
+
+// directive processes a single non-empty, non-comment line.
+func (rc *Inputrc) directive(line string) error {
+	if line[0] == '$' {
+		return rc.conditional(line)
+	}
+	if rc.skipping() {
+		return nil
+	}
+
+	if fields := strings.Fields(line); strings.ToLower(fields[0]) == "set" {
+		if len(fields) < 3 {
+			return fmt.Errorf("set requires a variable and a value")
+		}
+		return rc.set(strings.ToLower(fields[1]), fields[2])
+	}
+
+	var keys, rest string
+	var err error
+	if line[0] == '"' {
+		end := quoteEnd(line)
+		if end < 0 {
+			return fmt.Errorf("unterminated key sequence")
+		}
+		if keys, err = unescape(line[1:end], true); err != nil {
+			return err
+		}
+		rest = strings.TrimSpace(line[end+1:])
+		if !strings.HasPrefix(rest, ":") {
+			return fmt.Errorf("missing ':' after key sequence")
+		}
+	} else {
+		colon := strings.Index(line, ":")
+		if colon < 0 {
+			return fmt.Errorf("missing ':' after key name")
+		}
+		if keys, err = keyname(line[:colon]); err != nil {
+			return err
+		}
+		rest = line[colon:]
+	}
+	return rc.bind(keys, strings.TrimSpace(rest[1:]))
+}
+
+// conditional processes the $if, $else, $endif and $include directives.
+func (rc *Inputrc) conditional(line string) error {
+	directive, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		directive, arg = line[:i], strings.TrimSpace(line[i:])
+	}
+
+	switch directive {
+	case "$if":
+		rc.conds = append(rc.conds, rc.test(arg))
+	case "$else":
+		if len(rc.conds) == 0 {
+			return fmt.Errorf("$else without $if")
+		}
+		rc.conds[len(rc.conds)-1] = !rc.conds[len(rc.conds)-1]
+	case "$endif":
+		if len(rc.conds) == 0 {
+			return fmt.Errorf("$endif without $if")
+		}
+		rc.conds = rc.conds[:len(rc.conds)-1]
+	case "$include":
+		if rc.skipping() {
+			return nil
+		}
+		return rc.Load(arg)
+	default:
+		return fmt.Errorf("unknown directive %q", directive)
+	}
+	return nil
+}
+
+// test evaluates the condition of an $if directive.
+func (rc *Inputrc) test(cond string) bool {
+	switch {
+	case strings.HasPrefix(cond, "mode="):
+		return cond[len("mode="):] == rc.Variables["editing-mode"]
+	case strings.HasPrefix(cond, "term="):
+		name := cond[len("term="):]
+		short := rc.Term
+		if i := strings.Index(short, "-"); i >= 0 {
+			short = short[:i]
+		}
+		return name == rc.Term || name == short
+	case strings.ContainsAny(cond, "=<>"):
+		// Version and variable comparisons are not supported
+		return false
+	default:
+		return strings.EqualFold(cond, rc.App)
+	}
+}
+
+// set processes a "set" directive.
+func (rc *Inputrc) set(name, value string) error {
+	switch strings.ToLower(value) {
+	case "on", "off":
+		value = strings.ToLower(value)
+	}
+
+	switch name {
+	case "editing-mode":
+		if value != "emacs" && value != "vi" {
+			return fmt.Errorf("unknown editing mode %q", value)
+		}
+		rc.target = "emacs"
+		if value == "vi" {
+			rc.target = "vi-insert"
+		}
+	case "keymap":
+		switch value {
+		case "emacs", "emacs-standard", "emacs-meta", "emacs-ctlx":
+			rc.target = value
+		case "vi-insert":
+			rc.target = value
+		case "vi", "vi-command", "vi-move":
+			rc.target = ""
+		default:
+			return fmt.Errorf("unknown keymap %q", value)
+		}
+		return nil
+	}
+	rc.Variables[name] = value
+	return nil
+}
+
+// bind processes a key binding to the given function name or quoted macro.
+func (rc *Inputrc) bind(keys, to string) error {
+	var keymap *Keymap
+	switch rc.target {
+	case "":
+		return nil
+	case "emacs-meta":
+		keys = "\x1b" + keys
+	case "emacs-ctlx":
+		keys = "\x18" + keys
+	case "vi-insert":
+		keymap = rc.keymaps["vi-insert"]
+	}
+	if keymap == nil {
+		keymap = rc.keymaps["emacs"]
+	}
+
+	if len(to) > 0 && (to[0] == '"' || to[0] == '\'') {
+		end := quoteEnd(to)
+		if end < 0 {
+			return fmt.Errorf("unterminated macro")
+		}
+		macro, err := unescape(to[1:end], false)
+		if err != nil {
+			return err
+		}
+		keymap.BindFunc(keys, func(e *Editor) { e.Insert(macro) })
+		return nil
+	}
+
+	if fields := strings.Fields(to); len(fields) > 0 {
+		to = fields[0]
+	}
+	return keymap.Bind(keys, strings.ToLower(to))
+}
+
+// quoteEnd returns the index of the quote which closes the quoted string at
+// the beginning of s, or -1 if it is not closed.
+func quoteEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case s[0]:
+			return i
+		}
+	}
+	return -1
+}
+
+// inputrcKeys holds the key names understood in inputrc files.
+var inputrcKeys = map[string]byte{
+	"del":     DEL,
+	"esc":     ESC,
+	"escape":  ESC,
+	"lfd":     LF,
+	"newline": LF,
+	"ret":     CR,
+	"return":  CR,
+	"rubout":  DEL,
+	"space":   ' ',
+	"spc":     ' ',
+	"tab":     TAB,
+}
+
+// keyname converts a key name such as "Control-u" or "Meta-Rubout" to the
+// bytes sent for it.
+func keyname(name string) (string, error) {
+	var ctrl, meta bool
+	for {
+		lower := strings.ToLower(name)
+		switch {
+		case strings.HasPrefix(lower, "control-"):
+			ctrl, name = true, name[len("control-"):]
+		case strings.HasPrefix(lower, "c-"):
+			ctrl, name = true, name[len("c-"):]
+		case strings.HasPrefix(lower, "meta-"):
+			meta, name = true, name[len("meta-"):]
+		case strings.HasPrefix(lower, "m-"):
+			meta, name = true, name[len("m-"):]
+		default:
+			ch, ok := inputrcKeys[lower]
+			if !ok && len(name) == 1 {
+				ch, ok = name[0], true
+			}
+			if !ok {
+				return "", fmt.Errorf("unknown key %q", name)
+			}
+			if ctrl {
+				ch = control(ch)
+			}
+			if meta {
+				return string([]byte{ESC, ch}), nil
+			}
+			return string(ch), nil
+		}
+	}
+}
+
+// control returns the control character for ch, as sent by pressing
+// Control-ch.
+func control(ch byte) byte {
+	if ch == '?' {
+		return DEL
+	}
+	return ch & 0x1f
+}
+
+// unescape processes the backslash escapes in a quoted key sequence or macro.
+// The \C- and \M- prefixes are only understood in key sequences.
+func unescape(s string, keyseq bool) (string, error) {
+	var out []byte
+	var ctrl, meta bool
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch == '\\' && i+1 < len(s) {
+			i++
+			switch esc := s[i]; {
+			case keyseq && (esc == 'C' || esc == 'M') && strings.HasPrefix(s[i+1:], "-") && i+2 < len(s):
+				if esc == 'C' {
+					ctrl = true
+				} else {
+					meta = true
+				}
+				i++
+				continue
+			case esc == 'e':
+				ch = ESC
+			case esc == 'a':
+				ch = BEL
+			case esc == 'b':
+				ch = BS
+			case esc == 'd':
+				ch = DEL
+			case esc == 'f':
+				ch = FF
+			case esc == 'n':
+				ch = LF
+			case esc == 'r':
+				ch = CR
+			case esc == 't':
+				ch = TAB
+			case esc == 'v':
+				ch = VT
+			case esc >= '0' && esc <= '7':
+				end := i + 1
+				for end < len(s) && end < i+3 && s[end] >= '0' && s[end] <= '7' {
+					end++
+				}
+				n, _ := strconv.ParseUint(s[i:end], 8, 8)
+				ch, i = byte(n), end-1
+			case esc == 'x':
+				end := i + 1
+				for end < len(s) && end < i+3 && strings.IndexByte("0123456789abcdefABCDEF", s[end]) >= 0 {
+					end++
+				}
+				n, err := strconv.ParseUint(s[i+1:end], 16, 8)
+				if err != nil {
+					return "", fmt.Errorf("bad hex escape in %q", s)
+				}
+				ch, i = byte(n), end-1
+			default:
+				ch = esc
+			}
+		}
+		if ctrl {
+			ch, ctrl = control(ch), false
+		}
+		if meta {
+			out, meta = append(out, ESC), false
+		}
+		out = append(out, ch)
+	}
+	return string(out), nil
+}
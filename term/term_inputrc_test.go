@@ -0,0 +1,212 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testInputrc = `
+# Comments and blank lines are ignored
+
+set editing-mode emacs
+set completion-ignore-case On
+set bell-style none
+
+"\C-p": history-search-backward
+"\C-n": history-search-forward
+Control-k: kill-line
+Meta-Rubout: unix-word-rubout
+"\e[1~": beginning-of-line
+"\C-xh": "hello"
+"\C-xq": quoted-insert
+
+$if mode=emacs
+"\C-a": beginning-of-line
+$else
+"\C-a": end-of-line
+$endif
+
+$if term=xterm
+"\C-e": end-of-line
+$endif
+
+$if goat
+"\C-u": unix-line-discard
+$endif
+
+$if other
+"\C-w": unix-word-rubout
+$endif
+
+set keymap emacs-meta
+"b": backward-word
+
+set keymap vi-command
+"\C-t": kill-line
+
+bogus line
+`
+
+func TestInputrc(t *testing.T) {
+	rc := NewInputrc("goat")
+	rc.Term = "xterm-256color"
+	if err := rc.Parse(strings.NewReader(testInputrc), "test"); err != nil {
+		t.Fatalf("Parse: %s", err)
+	}
+
+	k := rc.Keymap()
+	for keys, want := range map[string]string{
+		"\x10":     "history-search-backward",
+		"\x0e":     "history-search-forward",
+		"\x0b":     "kill-line",
+		"\x1b\x7f": "unix-word-rubout",
+		"\x1b[1~":  "beginning-of-line",
+		"\x18h":    "",
+		"\x01":     "beginning-of-line",
+		"\x05":     "end-of-line",
+		"\x15":     "unix-line-discard",
+		"\x1bb":    "backward-word",
+		"\r":       "accept-line",
+	} {
+		if got, ok := k.Lookup(keys); !ok || got != want {
+			t.Errorf("Lookup(%q) = %q, %v; want %q, true", keys, got, ok, want)
+		}
+	}
+	for _, keys := range []string{"\x17", "\x14", "\x18q"} {
+		if got, ok := k.Lookup(keys); ok {
+			t.Errorf("Lookup(%q) = %q, want unbound", keys, got)
+		}
+	}
+
+	for name, want := range map[string]string{
+		"editing-mode":           "emacs",
+		"completion-ignore-case": "on",
+		"bell-style":             "none",
+	} {
+		if got := rc.Variables[name]; got != want {
+			t.Errorf("Variables[%q] = %q, want %q", name, got, want)
+		}
+	}
+
+	if got, want := len(rc.Ignored), 2; got != want {
+		t.Errorf("len(Ignored) = %d, want %d", got, want)
+	}
+	for _, ignored := range rc.Ignored {
+		t.Logf("Ignored: %s", ignored)
+	}
+}
+
+func TestInputrcVi(t *testing.T) {
+	rc := NewInputrc("goat")
+	rc.Parse(strings.NewReader(testInputrc+"set editing-mode vi\n\"\\C-p\": kill-line\n"), "test")
+
+	if got, _ := rc.Keymap().Lookup("\x10"); got != "kill-line" {
+		t.Errorf("vi Lookup(C-p) = %q, want %q", got, "kill-line")
+	}
+	if got, _ := rc.keymaps["emacs"].Lookup("\x10"); got != "history-search-backward" {
+		t.Errorf("emacs Lookup(C-p) = %q, want %q", got, "history-search-backward")
+	}
+}
+
+var inputrcTTYTests = []struct {
+	Desc   string
+	Chunks []string
+	Echo   []string
+	Output []string
+}{
+	{
+		Desc:   "history search",
+		Chunks: []string{"ab\n", "ac\n", "b\n", "a\x10", "\x10", "\x10", "\x0e", "\n"},
+		Echo: []string{
+			"a", "b", "\r\n",
+			"a", "c", "\r\n",
+			"b", "\r\n",
+			"a", "\bac",
+			"\b\bab",
+			"\b\bac",
+			"\r\n",
+		},
+		Output: []string{"ab", "\n", "ac", "\n", "b", "\n", "ac", "\n"},
+	},
+	{
+		Desc:   "macro",
+		Chunks: []string{"<\x18h>"},
+		Echo:   []string{"<", "hello", ">"},
+		Output: []string{"<hello>"},
+	},
+}
+
+func TestInputrcTTY(t *testing.T) {
+	rc := NewInputrc("goat")
+	rc.Parse(strings.NewReader(testInputrc), "test")
+
+	for _, test := range inputrcTTYTests {
+		desc := test.Desc
+		done := make(chan bool)
+		pipe := NewDoublePipe()
+		tty := NewTTY(pipe.Remote)
+		rc.Apply(tty)
+
+		go VerifyReads(t, desc, "read", tty, test.Output, done)
+		go VerifyReads(t, desc, "echo", pipe.Local, test.Echo, done)
+
+		for _, chunk := range test.Chunks {
+			if _, err := io.WriteString(pipe.Local, chunk); err != nil {
+				t.Errorf("%s: write(%q): %s", desc, chunk, err)
+			}
+		}
+
+		pipe.Local.Close()
+		<-done
+
+		pipe.Remote.Close()
+		<-done
+	}
+}
+
+func TestInputrcInclude(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("write %s: %s", name, err)
+		}
+		return path
+	}
+	self := write("self", "$include "+filepath.Join(dir, "self")+"\n")
+	write("b", "$if goat\n$include "+filepath.Join(dir, "a")+"\n$endif\n\"\\C-p\": kill-line\n")
+	a := write("a", "$include "+filepath.Join(dir, "b")+"\n")
+
+	for _, path := range []string{self, a} {
+		rc := NewInputrc("goat")
+		if err := rc.Load(path); err != nil {
+			t.Errorf("Load(%q): %s", path, err)
+		}
+		if len(rc.Ignored) != 1 || !strings.Contains(rc.Ignored[0], "recursive $include") {
+			t.Errorf("Load(%q) ignored %q, want a recursive $include", path, rc.Ignored)
+		}
+	}
+
+	rc := NewInputrc("goat")
+	rc.loading = make([]string, MaxIncludeDepth)
+	if err := rc.Load(self); err == nil {
+		t.Errorf("Load at MaxIncludeDepth succeeded")
+	}
+}
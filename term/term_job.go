@@ -0,0 +1,134 @@
+// +build linux darwin
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// stopProcess stops the process group, as the kernel would if ^Z were typed
+// on a terminal which was not in raw mode.
+var stopProcess = func() error {
+	return syscall.Kill(0, syscall.SIGTSTP)
+}
+
+// jobControl holds the state for SetJobControl.
+type jobControl struct {
+	cont    chan os.Signal // Receives SIGCONT
+	resumed chan bool      // Receives from the watcher when stopped (buffered)
+	quit    chan bool      // Closed to stop the watcher
+
+	lock    sync.Mutex // Locks stopped
+	stopped bool       // Whether the process was stopped by ^Z
+}
+
+// stop marks the process as stopped (or not) by ^Z.
+func (jobs *jobControl) stop(stopped bool) {
+	jobs.lock.Lock()
+	defer jobs.lock.Unlock()
+	jobs.stopped = stopped
+}
+
+// resume reports whether the process was stopped by ^Z, and if so, wakes the
+// stopped reader.
+func (jobs *jobControl) resume() bool {
+	jobs.lock.Lock()
+	defer jobs.lock.Unlock()
+	if !jobs.stopped {
+		return false
+	}
+	jobs.stopped = false
+	jobs.resumed <- true
+	return true
+}
+
+// SetJobControl enables or disables job control for the TTY, which requires
+// that it have a Terminal (see SetTerminal).
+//
+// When job control is enabled and ^Z is typed in Line or Frame mode, instead
+// of emitting it, the TTY restores the original terminal settings and stops
+// the process group with SIGTSTP.  When the process is continued (with SIGCONT,
+// for instance by the shell's "fg" command), the terminal is put back into raw
+// mode and the screen is redrawn (see Suspend).  This is also done if the
+// process is stopped and continued by other means, since the shell may have
+// changed the terminal settings in the meantime.
+func (t *TTY) SetJobControl(enabled bool) {
+	t.state.Lock()
+	defer t.state.Unlock()
+
+	if t.nojobs != nil {
+		t.nojobs()
+		t.nojobs, t.jobstop = nil, nil
+	}
+	if !enabled {
+		return
+	}
+
+	jobs := &jobControl{
+		cont:    make(chan os.Signal, 1),
+		resumed: make(chan bool, 1),
+		quit:    make(chan bool),
+	}
+	signal.Notify(jobs.cont, syscall.SIGCONT)
+	go t.watch(jobs)
+
+	t.nojobs = func() {
+		signal.Stop(jobs.cont)
+		close(jobs.quit)
+	}
+	t.jobstop = func() {
+		t.suspend(func() error {
+			jobs.stop(true)
+			if err := stopProcess(); err != nil {
+				jobs.stop(false)
+				return err
+			}
+			select {
+			case <-jobs.resumed:
+			case <-jobs.quit:
+			}
+			return nil
+		}, true)
+	}
+}
+
+// watch handles SIGCONT for SetJobControl until jobs.quit is closed.  If the
+// process was stopped by ^Z, the stopped reader is resumed; otherwise, the
+// terminal is put back into raw mode and the screen is redrawn.
+func (t *TTY) watch(jobs *jobControl) {
+	for {
+		select {
+		case <-jobs.cont:
+		case <-jobs.quit:
+			return
+		}
+
+		if jobs.resume() {
+			continue
+		}
+
+		t.state.Lock()
+		if t.term != nil {
+			t.term.Raw()
+		}
+		t.redraw()
+		t.state.Unlock()
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An Editor provides access to the line being edited in Line mode to functions
+// bound with Keymap.BindFunc.  It is only valid for the duration of the call.
+type Editor struct {
+	t    *TTY
+	keys string
+}
+
+// Keys returns the key sequence which caused the binding to be run.
+func (e *Editor) Keys() string { return e.keys }
+
+// Line returns the contents of the line being edited.
+func (e *Editor) Line() string { return string(e.t.output) }
+
+// Pos returns the offset of the cursor within the line being edited.
+func (e *Editor) Pos() int { return e.t.cursor() }
+
+// SetLine replaces the line being edited and places the cursor at the end.
+func (e *Editor) SetLine(line string) { e.t.replace([]byte(line)) }
+
+// Insert inserts the given string at the cursor.
+func (e *Editor) Insert(s string) { e.t.insert([]byte(s)...) }
+
+// Emit sends the given chunk to the reader of the TTY, as if it had been a
+// line.  The line being edited is unaffected.
+func (e *Editor) Emit(chunk string) { e.t.send([]byte(chunk)) }
+
+// Echo writes the given string directly to the interactive echo, if any.
+func (e *Editor) Echo(s string) { e.t.echo([]byte(s)...) }
+
+// Suspend is like TTY.Suspend, but it can be called from a binding.
+func (e *Editor) Suspend(fn func() error) error {
+	if e.t.term == nil {
+		return errNoTerminal
+	}
+	return e.t.suspend(fn, true)
+}
+
+// Do performs the named action (see Keymap.Bind) as if its key had been
+// pressed.
+func (e *Editor) Do(action string) error {
+	fn, ok := actions[action]
+	if !ok {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	fn(e.t, e.keys)
+	return nil
+}
+
+// actions holds the named actions which can be bound to keys.  The names
+// match those used by GNU readline where possible.
+var actions = map[string]func(t *TTY, keys string){
+	"accept-line":             (*TTY).acceptLine,
+	"backward-char":           (*TTY).backwardChar,
+	"backward-delete-char":    (*TTY).backwardDeleteChar,
+	"backward-word":           (*TTY).backwardWord,
+	"beginning-of-line":       (*TTY).beginningOfLine,
+	"delete-char":             (*TTY).deleteChar,
+	"edit-line":               (*TTY).editLine,
+	"end-of-line":             (*TTY).endOfLine,
+	"forward-char":            (*TTY).forwardChar,
+	"forward-word":            (*TTY).forwardWord,
+	"history-search-backward": func(t *TTY, _ string) { t.hsearch(-1) },
+	"history-search-forward":  func(t *TTY, _ string) { t.hsearch(+1) },
+	"kill-line":               (*TTY).killLine,
+	"previous-history":        func(t *TTY, _ string) { t.hprev() },
+	"self-insert":             func(t *TTY, keys string) { t.insert([]byte(keys)...) },
+	"unix-line-discard":       (*TTY).unixLineDiscard,
+	"unix-word-rubout":        (*TTY).unixWordRubout,
+}
+
+// A binding is a single entry in a Keymap.
+type binding struct {
+	action string // The named action, or "" for a function
+	fn     func(t *TTY, keys string)
+}
+
+// A Keymap maps key sequences to the actions they perform in Line mode.
+//
+// A key sequence is the exact bytes sent by the terminal for one or more keys,
+// such as "\x01" for C-a, "\x1b[A" for the up arrow, or "\x18\x05" for C-x
+// C-e.  ParseKeys can be used to construct these.  Keys which are not bound
+// retain their basic behavior: printing characters are inserted, control
+// characters are emitted by themselves, and escape sequences are emitted as
+// part of the line.
+//
+// A key sequence which is the beginning of another (such as C-x above) is a
+// prefix key; it does nothing on its own, and if it is not followed by the
+// rest of a binding, it is discarded.
+type Keymap struct {
+	keys map[string]binding
+}
+
+// NewKeymap returns a new Keymap with no bindings.
+func NewKeymap() *Keymap {
+	return &Keymap{
+		keys: make(map[string]binding),
+	}
+}
+
+// DefaultKeymap returns a new Keymap with the default bindings for Line mode
+// (see the package comment), as well as C-x C-e for edit-line.  Most control
+// characters are not bound by default, so that they will be emitted by
+// themselves.
+func DefaultKeymap() *Keymap {
+	k := NewKeymap()
+	for keys, action := range map[string]string{
+		"\r":       "accept-line",
+		"\n":       "accept-line",
+		"\b":       "backward-delete-char",
+		"\x7f":     "backward-delete-char",
+		"\x1b[A":   "previous-history",
+		"\x1b[B":   "end-of-line",
+		"\x1b[C":   "forward-char",
+		"\x1b[D":   "backward-char",
+		"\x1b[F":   "end-of-line",
+		"\x1b[4~":  "end-of-line",
+		"\x1b[8~":  "end-of-line",
+		"\x1bf":    "forward-word",
+		"\x18\x05": "edit-line",
+	} {
+		k.Bind(keys, action)
+	}
+	return k
+}
+
+// Bind binds the key sequence to the named action, replacing any previous
+// binding.  The following actions are available:
+//   accept-line           Finish the line and emit it
+//   backward-char         Move back one character
+//   backward-delete-char  Delete the character before the cursor
+//   backward-word         Move back to the beginning of a word
+//   beginning-of-line     Move to the beginning of the line
+//   delete-char           Delete the character under the cursor
+//   edit-line             Edit the line with $VISUAL or $EDITOR (see below)
+//   end-of-line           Move to the end of the line (or accept a suggestion)
+//   forward-char          Move forward one character (or accept a suggestion)
+//   forward-word          Move forward past a word (or accept part of a suggestion)
+//   history-search-backward
+//                         Restore the previous line beginning with the text
+//                         before the cursor (repeat to search further back)
+//   history-search-forward
+//                         Restore the next such line (after searching backward)
+//   kill-line             Delete from the cursor to the end of the line
+//   previous-history      Restore the previous line
+//   self-insert           Insert the key sequence itself
+//   unix-line-discard     Delete from the beginning of the line to the cursor
+//   unix-word-rubout      Delete the word before the cursor
+// An error is returned if the action is not known.
+//
+// The edit-line action writes the line to a temporary file and runs the
+// editor on it, and then loads the edited file back into the line (with any
+// newlines replaced by spaces).  The terminal is restored to its original
+// settings while the editor is running, so it requires that the TTY have a
+// Terminal (see SetTerminal); otherwise, it just rings the bell.
+func (k *Keymap) Bind(keys, action string) error {
+	fn, ok := actions[action]
+	if !ok {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	k.keys[keys] = binding{action, fn}
+	return nil
+}
+
+// BindFunc binds the key sequence to the given function, replacing any
+// previous binding.  The function is called from the goroutine processing
+// input, so no input will be processed until it returns.
+func (k *Keymap) BindFunc(keys string, fn func(e *Editor)) {
+	k.keys[keys] = binding{fn: func(t *TTY, keys string) {
+		fn(&Editor{t, keys})
+	}}
+}
+
+// Unbind removes the binding for the key sequence, if any.
+func (k *Keymap) Unbind(keys string) {
+	delete(k.keys, keys)
+}
+
+// Lookup returns the action bound to the key sequence.  If the key sequence
+// is bound to a function, the action is "".
+func (k *Keymap) Lookup(keys string) (action string, ok bool) {
+	b, ok := k.keys[keys]
+	return b.action, ok
+}
+
+// prefix returns true if keys is the beginning of a longer bound sequence.
+func (k *Keymap) prefix(keys string) bool {
+	for bound := range k.keys {
+		if len(bound) > len(keys) && strings.HasPrefix(bound, keys) {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a copy of the keymap which shares no state with it.
+func (k *Keymap) clone() *Keymap {
+	c := NewKeymap()
+	for keys, b := range k.keys {
+		c.keys[keys] = b
+	}
+	return c
+}
+
+// namedKeys holds the keys which ParseKeys understands by name.
+var namedKeys = map[string]string{
+	"RET":     "\r",
+	"LFD":     "\n",
+	"TAB":     "\t",
+	"ESC":     "\x1b",
+	"DEL":     "\x7f",
+	"SPC":     " ",
+	"<up>":    "\x1b[A",
+	"<down>":  "\x1b[B",
+	"<right>": "\x1b[C",
+	"<left>":  "\x1b[D",
+	"<home>":  "\x1b[H",
+	"<end>":   "\x1b[F",
+}
+
+// ParseKeys converts a readable description of a key sequence into the bytes
+// which are sent by the terminal for it.  The description is a space-separated
+// list of keys in the style of emacs, for instance:
+//   C-a        Control-a
+//   M-f        Meta-f (sent as ESC f)
+//   C-x C-e    Control-x followed by Control-e
+//   C-M-h      Meta-Control-h
+//   RET        Named keys: RET LFD TAB ESC DEL SPC
+//   <up>       Arrows: <up> <down> <left> <right> <home> <end>
+//   q          A single character stands for itself
+func ParseKeys(desc string) (string, error) {
+	var keys []byte
+	for _, key := range strings.Fields(desc) {
+		if named, ok := namedKeys[key]; ok {
+			keys = append(keys, named...)
+			continue
+		}
+
+		var ctrl, meta bool
+		for len(key) > 2 && key[1] == '-' {
+			switch key[0] {
+			case 'C':
+				ctrl = true
+			case 'M':
+				meta = true
+			default:
+				return "", fmt.Errorf("unknown modifier in key %q", key)
+			}
+			key = key[2:]
+		}
+		if named, ok := namedKeys[key]; ok && len(named) == 1 {
+			key = named
+		}
+		if len(key) != 1 {
+			return "", fmt.Errorf("unknown key %q", key)
+		}
+
+		ch := key[0]
+		if ctrl {
+			switch {
+			case ch == '?':
+				ch = DEL
+			case ch == ' ':
+				ch = NUL
+			case ch >= '@' && ch <= '~':
+				ch &= 0x1f
+			default:
+				return "", fmt.Errorf("no control character for %q", key)
+			}
+		}
+		if meta {
+			keys = append(keys, ESC)
+		}
+		keys = append(keys, ch)
+	}
+	return string(keys), nil
+}
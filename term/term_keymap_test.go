@@ -0,0 +1,183 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+var parseKeysTests = []struct {
+	Desc string
+	Keys string
+	Err  bool
+}{
+	{Desc: "q", Keys: "q"},
+	{Desc: "C-a", Keys: "\x01"},
+	{Desc: "C-x C-e", Keys: "\x18\x05"},
+	{Desc: "M-f", Keys: "\x1bf"},
+	{Desc: "C-M-h", Keys: "\x1b\b"},
+	{Desc: "C-?", Keys: "\x7f"},
+	{Desc: "C-SPC", Keys: "\x00"},
+	{Desc: "RET TAB", Keys: "\r\t"},
+	{Desc: "<up> <end>", Keys: "\x1b[A\x1b[F"},
+	{Desc: "X-a", Err: true},
+	{Desc: "foo", Err: true},
+	{Desc: "C-1", Err: true},
+}
+
+func TestParseKeys(t *testing.T) {
+	for _, test := range parseKeysTests {
+		keys, err := ParseKeys(test.Desc)
+		if got, want := err != nil, test.Err; got != want {
+			t.Errorf("ParseKeys(%q) error = %v, want error: %v", test.Desc, err, want)
+			continue
+		}
+		if got, want := keys, test.Keys; got != want {
+			t.Errorf("ParseKeys(%q) = %q, want %q", test.Desc, got, want)
+		}
+	}
+}
+
+var keymapTests = []struct {
+	Desc   string
+	Keymap func(k *Keymap)
+	Chunks []string
+	Echo   []string
+	Output []string
+}{
+	{
+		Desc:   "default control",
+		Chunks: []string{"ab\x01"},
+		Echo:   []string{"a", "b"},
+		Output: []string{"ab", "\x01"},
+	},
+	{
+		Desc: "unbind",
+		Keymap: func(k *Keymap) {
+			k.Unbind("\r")
+		},
+		Chunks: []string{"ab\r"},
+		Echo:   []string{"a", "b"},
+		Output: []string{"ab", "\r"},
+	},
+	{
+		Desc: "beginning-of-line",
+		Keymap: func(k *Keymap) {
+			k.Bind("\x01", "beginning-of-line")
+		},
+		Chunks: []string{"bc\x01a"},
+		Echo:   []string{"b", "c", "\b\b", "abc\b\b"},
+		Output: []string{"abc"},
+	},
+	{
+		Desc: "kill-line",
+		Keymap: func(k *Keymap) {
+			k.Bind("\x0b", "kill-line")
+		},
+		Chunks: []string{"abcd", "\x1b[D", "\x1b[D", "\x0b"},
+		Echo:   []string{"a", "b", "c", "d", "\x1b[D", "\x1b[D", "  \b\b"},
+		Output: []string{"ab"},
+	},
+	{
+		Desc: "delete-char",
+		Keymap: func(k *Keymap) {
+			k.Bind("\x04", "delete-char")
+		},
+		Chunks: []string{"abc", "\x1b[D", "\x1b[D", "\x04"},
+		Echo:   []string{"a", "b", "c", "\x1b[D", "\x1b[D", "c \b\b"},
+		Output: []string{"ac"},
+	},
+	{
+		Desc: "unix-line-discard",
+		Keymap: func(k *Keymap) {
+			k.Bind("\x15", "unix-line-discard")
+		},
+		Chunks: []string{"abcd", "\x1b[D", "\x15"},
+		Echo:   []string{"a", "b", "c", "d", "\x1b[D", "\b\b\bd   \b\b\b\b"},
+		Output: []string{"d"},
+	},
+	{
+		Desc: "unix-word-rubout",
+		Keymap: func(k *Keymap) {
+			k.Bind("\x17", "unix-word-rubout")
+		},
+		Chunks: []string{"one two", "\x17"},
+		Echo:   []string{"o", "n", "e", " ", "t", "w", "o", "\b\b\b   \b\b\b"},
+		Output: []string{"one "},
+	},
+	{
+		Desc: "chord",
+		Keymap: func(k *Keymap) {
+			k.BindFunc("\x18\x05", func(e *Editor) {
+				e.SetLine(strings.ToUpper(e.Line()))
+			})
+		},
+		Chunks: []string{"ab", "\x18", "\x05"},
+		Echo:   []string{"a", "b", "\b\bAB"},
+		Output: []string{"AB"},
+	},
+	{
+		Desc: "chord miss",
+		Keymap: func(k *Keymap) {
+			k.Bind("\x18\x05", "kill-line")
+		},
+		Chunks: []string{"a\x18b\x18\x01"},
+		Echo:   []string{"a", "b"},
+		Output: []string{"ab", "\x01"},
+	},
+	{
+		Desc: "escape func",
+		Keymap: func(k *Keymap) {
+			k.BindFunc("\x1b[5~", func(e *Editor) {
+				e.Emit(e.Keys())
+				e.Insert("!")
+			})
+		},
+		Chunks: []string{"ab", "\x1b[5~", "c"},
+		Echo:   []string{"a", "b", "!", "c"},
+		Output: []string{"\x1b[5~", "ab!c"},
+	},
+}
+
+func TestKeymap(t *testing.T) {
+	for _, test := range keymapTests {
+		desc := test.Desc
+		done := make(chan bool)
+		pipe := NewDoublePipe()
+		tty := NewTTY(pipe.Remote)
+		if test.Keymap != nil {
+			k := DefaultKeymap()
+			test.Keymap(k)
+			tty.SetKeymap(k)
+		}
+
+		go VerifyReads(t, desc, "read", tty, test.Output, done)
+		go VerifyReads(t, desc, "echo", pipe.Local, test.Echo, done)
+
+		for _, chunk := range test.Chunks {
+			if _, err := io.WriteString(pipe.Local, chunk); err != nil {
+				t.Errorf("%s: write(%q): %s", desc, chunk, err)
+			}
+		}
+
+		pipe.Local.Close()
+		<-done
+
+		pipe.Remote.Close()
+		<-done
+	}
+}
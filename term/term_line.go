@@ -14,22 +14,78 @@
 
 package term
 
+import (
+	"bytes"
+)
+
 // hpush (history push) stores the line for later reuse if it
 // is not an escape sequence and contains characters.
 //
 // Side effects: (only if output is nonzero and not an escape sequence)
 // - t.last will contain a copy of output
+// - t.history will end with t.last and contain no other copies of it
 func (t *TTY) hpush() {
 	if len(t.output) == 0 || t.output[0] < 32 {
 		return
 	}
 	t.last = make([]byte, len(t.output))
 	copy(t.last, t.output)
+
+	history := t.history[:0]
+	for _, prev := range t.history {
+		if !bytes.Equal(prev, t.last) {
+			history = append(history, prev)
+		}
+	}
+	if len(history) >= HistoryLength {
+		history = history[len(history)-HistoryLength+1:]
+	}
+	t.history = append(history, t.last)
 }
 
 // hprev (history previous) replaces the current output with the last
 // saved line (unless no line has been saved).
 //
+// Side effects:
+// - t.output will contain a copy of t.last
+// - t.linepos will be -1
+func (t *TTY) hprev() {
+	if len(t.last) == 0 {
+		return
+	}
+	t.replace(t.last)
+}
+
+// hsearch (history search) replaces the current output with the nearest line
+// in the history in the given direction (-1 for older, +1 for newer) which
+// begins with the text before the cursor.  If the current output is the result
+// of the previous search, the search continues from there with the same
+// prefix.  Nothing is done if no line is found.
+//
+// Side effects: (only if a line is found)
+// - t.output will contain a copy of the line
+// - t.hfound and t.hprefix will identify the line and prefix
+// - t.linepos will be -1
+func (t *TTY) hsearch(dir int) {
+	prefix, start := t.output[:t.cursor()], len(t.history)
+	if dir > 0 {
+		start = -1
+	}
+	if t.hfound >= 0 && t.hfound < len(t.history) && bytes.Equal(t.output, t.history[t.hfound]) {
+		prefix, start = t.hprefix, t.hfound
+	}
+	for i := start + dir; i >= 0 && i < len(t.history); i += dir {
+		if bytes.HasPrefix(t.history[i], prefix) {
+			t.hprefix = append([]byte(nil), prefix...)
+			t.hfound = i
+			t.replace(t.history[i])
+			return
+		}
+	}
+}
+
+// replace replaces the current output with a copy of line.
+//
 // To echo the new line, the following is written:
 //   <home><line><spaces><backspaces>
 // Where <line> is the new output <spaces> and <backspaces> are present if the
@@ -37,28 +93,14 @@ func (t *TTY) hpush() {
 // and <home> is enough backspace characters to get to the beginning of the
 // current line of text.
 //
-// Preconditions:
-// - Must be called within an escape sequence
 // Side effects:
-// - t.output will contain a copy of t.last or will contain preescape
-// - t.preescape will be nil
-func (t *TTY) hprev() {
-	if len(t.last) == 0 {
-		t.output = t.preescape
-		t.preescape = nil
-		return
-	}
-
-	t.output = make([]byte, len(t.last))
-	copy(t.output, t.last)
+// - t.output will contain a copy of line
+// - t.linepos will be -1
+func (t *TTY) replace(line []byte) {
+	width, home := len(t.output), t.cursor()
 
-	width := len(t.preescape)
-	t.preescape = nil
-
-	home := width
-	if t.linepos >= 0 {
-		home = t.linepos
-	}
+	t.output = make([]byte, len(line))
+	copy(t.output, line)
 	t.linepos = -1
 
 	if t.screen != nil {
@@ -79,176 +121,398 @@ func (t *TTY) hprev() {
 	}
 }
 
-// linechar processes the next character of input in line mode.
+// cursor returns the offset of the cursor within the current output.
+func (t *TTY) cursor() int {
+	if t.linepos < 0 {
+		return len(t.output)
+	}
+	return t.linepos
+}
+
+// move moves the cursor to the given offset within the current output by
+// echoing backspaces (to move left) or the intervening characters (to move
+// right).
 //
-// If ch is ESC, it begins a new escape sequence by storing the current output
-// into preescape and creating a new 8-cap byte slice for the escape sequence.
+// Side effects:
+// - t.linepos will be pos, or -1 if pos is the end of the line
+func (t *TTY) move(pos int) {
+	if cur := t.cursor(); pos < cur {
+		t.echo(bytes.Repeat([]byte{'\b'}, cur-pos)...)
+	} else {
+		t.echo(t.output[cur:pos]...)
+	}
+	t.linepos = pos
+	if pos == len(t.output) {
+		t.linepos = -1
+	}
+}
+
+// insert inserts the given characters at the cursor.  If the cursor is not at
+// the end of the line, the remainder of the line is echoed after them and the
+// cursor is returned to the end of the inserted characters.
+//
+// Side effects:
+// - t.output has the characters inserted into it
+// - t.linepos is advanced past the inserted characters (if it is >= 0)
+func (t *TTY) insert(chars ...byte) {
+	if t.linepos < 0 {
+		t.echo(chars...)
+		t.output = append(t.output, chars...)
+		return
+	}
+
+	// Insert on screen
+	if t.screen != nil {
+		delta := len(t.output) - t.linepos
+		overwrite := make([]byte, len(chars)+2*delta)
+		copy(overwrite, chars)
+		copy(overwrite[len(chars):], t.output[t.linepos:])
+		for i := 0; i < delta; i++ {
+			overwrite[len(chars)+delta+i] = '\b'
+		}
+		t.echo(overwrite...)
+	}
+	// Insert into output
+	t.output = append(t.output[:t.linepos],
+		append(append([]byte{}, chars...), t.output[t.linepos:]...)...)
+	t.linepos += len(chars)
+}
+
+// erase deletes the characters between from and to from the current output.
 //
-// If ch is a low nonprinting character, the current output is written and then
-// the control character is written by itself.  This is to allow easy detection
-// of things like ^C and ^D.
+// To echo the deletion, the following is written:
+//   <back><tail><spaces><backspaces>
+// Where <back> is enough backspaces to go from the cursor to from, <tail> is
+// the remainder of the line after to, and <spaces> overwrite the characters
+// which are no longer on the line.  The <backspaces> return the cursor to
+// from.
+//
+// Preconditions:
+// - 0 <= from <= t.cursor() and from <= to <= len(t.output)
+// Side effects:
+// - t.output has the characters removed
+// - t.linepos will be from, or -1 if from is the end of the line
+func (t *TTY) erase(from, to int) {
+	if from == to {
+		return
+	}
+
+	// Delete onscreen
+	if t.screen != nil {
+		back, tail, gap := t.cursor()-from, t.output[to:], to-from
+		overwrite := make([]byte, 0, back+2*(len(tail)+gap))
+		overwrite = append(overwrite, bytes.Repeat([]byte{'\b'}, back)...)
+		overwrite = append(overwrite, tail...)
+		overwrite = append(overwrite, bytes.Repeat([]byte{' '}, gap)...)
+		overwrite = append(overwrite, bytes.Repeat([]byte{'\b'}, len(tail)+gap)...)
+		t.echo(overwrite...)
+	}
+	// Delete from output
+	t.output = append(t.output[:from], t.output[to:]...)
+	t.linepos = from
+	if from == len(t.output) {
+		t.linepos = -1
+	}
+}
+
+// lineInput processes the input in Line (and Frame) mode, a character at a
+// time, after applying the translations of the discipline.
 //
-// If ch is BS (and there are characters in output), the length of output is
-// shortened by one and a "\b \b" sequence is echoed to blank the space on the
-// console.
+// Side Effects (possible):
+// - lineesc() or linechar() is called
+func (t *TTY) lineInput(input []byte) {
+	for _, ch := range input {
+		ch, ok := t.translate(ch)
+		if !ok {
+			continue
+		}
+		if t.escaping {
+			t.lineesc(ch)
+		} else {
+			t.linechar(ch)
+		}
+	}
+}
+
+// linechar processes the next character of input in line mode.
 //
-// If ch is carriage return or newline (some terminals emit one, some emit the
-// other), the output is written and then a the character is written, but in
-// both cases a CRLF is echoed.
+// If ch is ESC, it begins a new escape sequence by storing the current output
+// into preescape and creating a new 8-cap byte slice for the escape sequence.
 //
-// If ch is anything else (basicaly a printing character), it is echoed and
-// appended to output.
+// Otherwise, ch is a complete key, and it is processed by key().
 //
 // Side Effects (possible):
+// - t.escaping is true
 // - t.preescape points to a new/different slice
 // - t.output points to a new/different slice or has changed
-// - t.next has data sent over it
-// - hpush() is called
+// - key() is called
 func (t *TTY) linechar(ch byte) {
-	switch ch {
-	case ESC:
-		if len(t.output) > 0 {
-			t.preescape = t.output
-			t.output = make([]byte, 0, 8)
-		}
-		t.output = append(t.output, ESC)
-	case '\r', '\n':
-		t.echo('\r', '\n')
-		t.hpush()
-		fallthrough
-	case SOH, STX, ETX, EOT, ENQ, ACK, BEL, VT, FF, SO, SI, DLE, DC1,
-		DC2, DC3, DC4, NAK, SYN, ETB, CAN, EM, SUB, FS, GS, RS, US:
-		t.emit()
-		t.next <- []byte{ch}
-	case BS, DEL:
-		if len(t.output) == 0 || t.linepos == 0 {
-			break
-		}
-		if t.linepos > 0 {
-			// Delete onscreen
-			if t.screen != nil {
-				delta := len(t.output) - t.linepos
-				overwrite := make([]byte, 1+1+2*delta+1)
-				overwrite[0] = ch
-				copy(overwrite[1:], t.output[t.linepos:])
-				overwrite[1+delta] = ' '
-				for i := 0; i < delta+1; i++ {
-					overwrite[2+delta+i] = '\b'
-				}
-				t.echo(overwrite...)
-			}
-			// Delete from output
-			t.output = append(t.output[:t.linepos-1], t.output[t.linepos:]...)
-			t.linepos--
-			break
-		}
-		t.echo(ch, ' ', ch)
-		t.output = t.output[:len(t.output)-1]
-	default:
-		if t.linepos >= 0 {
-			// Insert on screen
-			if t.screen != nil {
-				delta := len(t.output) - t.linepos
-				overwrite := make([]byte, 1+2*delta)
-				overwrite[0] = ch
-				copy(overwrite[1:], t.output[t.linepos:])
-				for i := 0; i < delta; i++ {
-					overwrite[1+delta+i] = '\b'
-				}
-				t.echo(overwrite...)
-			}
-			// Insert into output
-			t.output = append(t.output[:t.linepos],
-				append([]byte{ch}, t.output[t.linepos:]...)...)
-			t.linepos++
-			break
-		}
-		t.echo(ch)
-		t.output = append(t.output, ch)
+	if ch != ESC {
+		t.key(string(ch))
+		return
+	}
+	if len(t.output) > 0 {
+		t.preescape = t.output
+		t.output = make([]byte, 0, 8)
 	}
+	t.output = append(t.output, ESC)
+	t.escaping = true
 }
 
 // lineesc processes the next character from a potential escape sequence in
 // line mode.
 //
-// If the second character is not [, then the original output is restored and
-// the queued bytes are echoed and the character is processed by char()
-//
-// The escape sequence ends with the first "printing" character (@ to ~) after
-// the <ESC>[ sequence, and that character indicates the action.  The following
-// actions are known:
-//   A - Up
-//   B - Down
-//   C - Right
-//   D - Left
-//   ~ - PageUp/PageDown
-// These have optional arguments before them, which are all currently ignored.
-// Most of them don't do anything, but these known escape sequences are not
-// written out.  If the escape sequence is not known, however, the original
-// output is restored with the escape sequence appended.
-//   Up    - loads the last saved line
-//   Down  - goes to the end of the current line
-//   Left  - goes one character closer to the beginning of the line
-//   Right - goes one character closer to the end of the line
+// If the second character is not [, then the escape sequence is complete (this
+// is how Alt-<key> is sent by most terminals).  Otherwise, the escape sequence
+// ends with the first "printing" character (@ to ~) after the <ESC>[ sequence.
+// These have optional arguments before the final character.
+//
+// Once the escape sequence is complete, the original output is restored and
+// the sequence is processed as a single key by key().
 //
 // Side Effects: (possible)
+// - t.escaping is false
 // - t.output refers to a new/different slice
-// - t.preescape refers to a new/different slice or nil
-// - char() is called
+// - t.preescape is nil
+// - key() is called
 func (t *TTY) lineesc(ch byte) {
-	if len(t.output) == 1 {
-		if ch != '[' {
-			t.echo(t.output...)
-			t.output = append(t.preescape, t.output...)
-			t.preescape = nil
-			t.linechar(ch)
-		} else {
-			t.output = append(t.output, ch)
-		}
+	t.output = append(t.output, ch)
+	if len(t.output) == 2 && ch == '[' {
 		return
 	}
-	t.output = append(t.output, ch)
-	if ch >= '@' && ch <= '~' {
-		switch ch {
-		case 'A': // up
-			t.hprev()
-			return
-		case 'B': // down
-			if t.linepos < 0 {
-				break
-			}
-			t.echo(t.preescape[t.linepos:]...)
-			t.linepos = -1
-		case 'C': // right
-			if len(t.preescape) == 0 {
-				break
-			}
-			if t.linepos < 0 {
-				break
-			}
-			t.echo(t.output...)
-			t.linepos++
-			if t.linepos == len(t.preescape) {
-				t.linepos = -1
-			}
-		case 'D': // left
-			if len(t.preescape) == 0 {
-				break
-			}
-			if t.linepos < 0 {
-				t.linepos = len(t.preescape)
-			}
-			if t.linepos > 0 {
-				t.echo(t.output...)
-				t.linepos--
-			}
-		case '~': // pgup(5~)/dn(6~)
-		default:
-			t.output = append(t.preescape, t.output...)
-			t.preescape = nil
-			return
+	if len(t.output) > 2 && (ch < '@' || ch > '~') {
+		return
+	}
+	seq := string(t.output)
+	t.output = t.preescape
+	t.preescape = nil
+	t.escaping = false
+	t.key(seq)
+}
+
+// key processes a complete key (a single character or escape sequence) in
+// line mode.
+//
+// If the key is one of the special characters of the discipline (see
+// SetDiscipline), it is processed by discipline() and any pending keys are
+// discarded.  Otherwise, if the key (together with any keys pending from
+// previous calls) is bound in the keymap, the binding is run.  If it is the
+// beginning of a longer binding, it is stored until the following keys are
+// known.  If it does not match any binding, the pending keys are discarded and
+// the key is processed by itself; if the key alone is not bound either, it is
+// processed by unbound().
+//
+// Any displayed suggestion is erased before the key is processed and the
+// suggestion for the resulting line is displayed afterward.
+//
+// Side Effects (possible):
+// - t.chord has changed
+// - t.hint has changed
+// - anything done by the binding
+func (t *TTY) key(k string) {
+	t.clearhint()
+	defer t.showhint()
+
+	if t.discipline(k) {
+		t.chord = ""
+		return
+	}
+
+	keys := t.chord + k
+	if b, ok := t.keymap.keys[keys]; ok {
+		t.chord = ""
+		b.fn(t, keys)
+		return
+	}
+	if t.keymap.prefix(keys) {
+		t.chord = keys
+		return
+	}
+	if t.chord != "" {
+		t.chord = ""
+		t.key(k)
+		return
+	}
+	t.unbound(k)
+}
+
+// unbound processes a key which is not bound in the keymap.
+//
+// If the key is a low nonprinting character, the current output is written and
+// then the control character is written by itself.  This is to allow easy
+// detection of things like ^C and ^D.  The exception is ^Z when job control is
+// enabled (see SetJobControl), which stops the process instead.
+//
+// If the key is a well-formed <ESC>[ escape sequence ending with ~ (such as
+// PageUp and PageDown), it is ignored.  Other well-formed <ESC>[ escape
+// sequences are appended to the output.  Neither is echoed by default.
+//
+// If the key is ESC followed by another character, the ESC is echoed and
+// appended to the output and the other character is processed by linechar().
+//
+// If the key is anything else (basicaly a printing character), it is inserted
+// at the cursor.
+//
+// Side Effects (possible):
+// - t.output points to a new/different slice or has changed
+// - a chunk is queued to be sent over t.next (see send)
+// - linechar() is called
+func (t *TTY) unbound(k string) {
+	switch ch := k[0]; {
+	case len(k) > 2 && ch == ESC:
+		if t.echoesc {
+			t.echo(caret([]byte(k)...)...)
+		}
+		if k[len(k)-1] != '~' {
+			t.output = append(t.output, k...)
 		}
-		t.output = t.preescape
-		t.preescape = nil
+	case len(k) == 2 && ch == ESC:
+		t.echo(ESC)
+		t.output = append(t.output, ESC)
+		t.linechar(k[1])
+	case ch == SUB && t.jobstop != nil && t.term != nil:
+		t.jobstop()
+	case ch == DEL || (ch > NUL && ch < ' ' && ch != TAB):
+		t.control(ch, k)
+	default:
+		t.insert([]byte(k)...)
+	}
+}
+
+// acceptLine finishes the current line.  A CRLF is echoed, the line is saved
+// in the history, and the output is written and then the key that ended it is
+// written by itself.
+//
+// Side effects:
+// - hpush() is called
+// - a chunk is queued to be sent over t.next (see send)
+func (t *TTY) acceptLine(k string) {
+	t.echo('\r', '\n')
+	t.hpush()
+	t.emit()
+	t.send([]byte(k))
+}
+
+// backwardDeleteChar deletes the character before the cursor.
+func (t *TTY) backwardDeleteChar(string) {
+	if cur := t.cursor(); cur > 0 {
+		t.erase(cur-1, cur)
+	}
+}
+
+// deleteChar deletes the character under the cursor.
+func (t *TTY) deleteChar(string) {
+	if t.linepos >= 0 {
+		t.erase(t.linepos, t.linepos+1)
+	}
+}
+
+// killLine deletes from the cursor to the end of the line.
+func (t *TTY) killLine(string) {
+	t.erase(t.cursor(), len(t.output))
+}
+
+// unixLineDiscard deletes from the beginning of the line to the cursor.
+func (t *TTY) unixLineDiscard(string) {
+	t.erase(0, t.cursor())
+}
+
+// unixWordRubout deletes the word before the cursor, using whitespace as the
+// word boundary.
+func (t *TTY) unixWordRubout(string) {
+	cur := t.cursor()
+	from := cur
+	for from > 0 && t.output[from-1] == ' ' {
+		from--
+	}
+	for from > 0 && t.output[from-1] != ' ' {
+		from--
+	}
+	t.erase(from, cur)
+}
+
+// backwardChar moves the cursor one character closer to the beginning of the
+// line, echoing the terminal's left arrow sequence to do so.
+func (t *TTY) backwardChar(string) {
+	if len(t.output) == 0 {
+		return
+	}
+	if t.linepos < 0 {
+		t.linepos = len(t.output)
+	}
+	if t.linepos > 0 {
+		t.echo(ESC, '[', 'D')
+		t.linepos--
+	}
+}
+
+// forwardChar moves the cursor one character closer to the end of the line,
+// echoing the terminal's right arrow sequence to do so.  If the cursor is
+// already at the end of the line, any suggestion is accepted instead.
+func (t *TTY) forwardChar(string) {
+	if t.linepos < 0 {
+		t.accept(t.hinted())
+		return
+	}
+	t.echo(ESC, '[', 'C')
+	t.linepos++
+	if t.linepos == len(t.output) {
+		t.linepos = -1
+	}
+}
+
+// beginningOfLine moves the cursor to the beginning of the line.
+func (t *TTY) beginningOfLine(string) {
+	if len(t.output) > 0 {
+		t.move(0)
+	}
+}
+
+// endOfLine moves the cursor to the end of the line.  If the cursor is
+// already at the end of the line, any suggestion is accepted instead.
+func (t *TTY) endOfLine(k string) {
+	if t.linepos < 0 {
+		t.forwardChar(k)
+		return
+	}
+	t.move(len(t.output))
+}
+
+// forwardWord moves the cursor past the next word, using whitespace as the
+// word boundary.  If the cursor is already at the end of the line, the next
+// word of any suggestion is accepted instead.
+func (t *TTY) forwardWord(string) {
+	if t.linepos < 0 {
+		hint := t.hinted()
+		t.accept(hint[:wordEnd(hint, 0)])
+		return
+	}
+	t.move(wordEnd(t.output, t.linepos))
+}
+
+// backwardWord moves the cursor to the beginning of the previous word, using
+// whitespace as the word boundary.
+func (t *TTY) backwardWord(string) {
+	pos := t.cursor()
+	for pos > 0 && t.output[pos-1] == ' ' {
+		pos--
+	}
+	for pos > 0 && t.output[pos-1] != ' ' {
+		pos--
+	}
+	if pos < len(t.output) {
+		t.move(pos)
+	}
+}
+
+// wordEnd returns the offset of the end of the first word in line at or after
+// pos, skipping any spaces which precede it.
+func wordEnd(line []byte, pos int) int {
+	for pos < len(line) && line[pos] == ' ' {
+		pos++
+	}
+	for pos < len(line) && line[pos] != ' ' {
+		pos++
 	}
+	return pos
 }
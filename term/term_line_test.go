@@ -330,3 +330,159 @@ func TestTerm(t *testing.T) {
 		<-done
 	}
 }
+
+var suggestTests = []struct {
+	Desc   string
+	Chunks []string
+	Echo   []string
+	Output []string
+}{
+	{
+		Desc:   "accept right",
+		Chunks: []string{"ab\n", "a", "\x1b[C", "\n"},
+		Echo: []string{
+			"a", "b", "\r\n",
+			"a", "\x1b[2mb\x1b[0m\b",
+			" \b", "b",
+			"\r\n",
+		},
+		Output: []string{"ab", "\n", "ab", "\n"},
+	},
+	{
+		Desc:   "typing",
+		Chunks: []string{"abc\n", "a", "b", "x"},
+		Echo: []string{
+			"a", "b", "c", "\r\n",
+			"a", "\x1b[2mbc\x1b[0m\b\b",
+			"  \b\b", "b", "\x1b[2mc\x1b[0m\b",
+			" \b", "x",
+		},
+		Output: []string{"abc", "\n", "abx"},
+	},
+	{
+		Desc:   "most recent",
+		Chunks: []string{"abc\n", "abd\n", "a"},
+		Echo: []string{
+			"a", "b", "c", "\r\n",
+			"a", "\x1b[2mbc\x1b[0m\b\b",
+			"  \b\b", "b", "\x1b[2mc\x1b[0m\b",
+			" \b", "d", "\r\n",
+			"a", "\x1b[2mbd\x1b[0m\b\b",
+		},
+		Output: []string{"abc", "\n", "abd", "\n", "a"},
+	},
+	{
+		Desc:   "alt-f",
+		Chunks: []string{"git commit\n", "g", "\x1bf", "\x1bf", "\n"},
+		Echo: []string{
+			"g", "i", "t", " ", "c", "o", "m", "m", "i", "t", "\r\n",
+			"g", "\x1b[2mit commit\x1b[0m\b\b\b\b\b\b\b\b\b",
+			"         \b\b\b\b\b\b\b\b\b", "it", "\x1b[2m commit\x1b[0m\b\b\b\b\b\b\b",
+			"       \b\b\b\b\b\b\b", " commit",
+			"\r\n",
+		},
+		Output: []string{"git commit", "\n", "git commit", "\n"},
+	},
+	{
+		Desc:   "left end end",
+		Chunks: []string{"abc\n", "ab", "\x1b[D", "\x1b[F", "\x1b[F", "\n"},
+		Echo: []string{
+			"a", "b", "c", "\r\n",
+			"a", "\x1b[2mbc\x1b[0m\b\b",
+			"  \b\b", "b", "\x1b[2mc\x1b[0m\b",
+			" \b", "\x1b[D",
+			"b", "\x1b[2mc\x1b[0m\b",
+			" \b", "c",
+			"\r\n",
+		},
+		Output: []string{"abc", "\n", "abc", "\n"},
+	},
+}
+
+func TestSuggest(t *testing.T) {
+	for _, test := range suggestTests {
+		desc := test.Desc
+		done := make(chan bool)
+		pipe := NewDoublePipe()
+		tty := NewTTY(pipe.Remote)
+		tty.SetAutosuggest(true)
+
+		go VerifyReads(t, desc, "read", tty, test.Output, done)
+		go VerifyReads(t, desc, "echo", pipe.Local, test.Echo, done)
+
+		for _, chunk := range test.Chunks {
+			if _, err := io.WriteString(pipe.Local, chunk); err != nil {
+				t.Errorf("%s: write(%q): %s", desc, chunk, err)
+			}
+		}
+
+		pipe.Local.Close()
+		<-done
+
+		pipe.Remote.Close()
+		<-done
+	}
+}
+
+var echoTests = []struct {
+	Desc     string
+	Controls bool
+	Escapes  bool
+	Chunks   []string
+	Echo     []string
+	Output   []string
+}{
+	{
+		Desc:   "off",
+		Chunks: []string{"a\x03", "\x1b[5~", "\x1b[5G"},
+		Echo:   []string{"a"},
+		Output: []string{"a", "\x03", "\x1b[5G"},
+	},
+	{
+		Desc:     "controls",
+		Controls: true,
+		Chunks:   []string{"a\x03", "\x04", "\x1a"},
+		Echo:     []string{"a", "^C", "^D", "^Z"},
+		Output:   []string{"a", "\x03", "\x04", "\x1a"},
+	},
+	{
+		Desc:     "controls FS",
+		Controls: true,
+		Chunks:   []string{"\x1c"},
+		Echo:     []string{"^\\"},
+		Output:   []string{"\x1c"},
+	},
+	{
+		Desc:    "escapes",
+		Escapes: true,
+		Chunks:  []string{"a", "\x1b[5~", "\x1b[5G"},
+		Echo:    []string{"a", "^[[5~", "^[[5G"},
+		Output:  []string{"a\x1b[5G"},
+	},
+}
+
+func TestEcho(t *testing.T) {
+	for _, test := range echoTests {
+		desc := test.Desc
+		done := make(chan bool)
+		pipe := NewDoublePipe()
+		tty := NewTTY(pipe.Remote)
+		tty.SetEchoControl(test.Controls)
+		tty.SetEchoEscapes(test.Escapes)
+
+		go VerifyReads(t, desc, "read", tty, test.Output, done)
+		go VerifyReads(t, desc, "echo", pipe.Local, test.Echo, done)
+
+		for _, chunk := range test.Chunks {
+			if _, err := io.WriteString(pipe.Local, chunk); err != nil {
+				t.Errorf("%s: write(%q): %s", desc, chunk, err)
+			}
+		}
+
+		pipe.Local.Close()
+		<-done
+
+		pipe.Remote.Close()
+		<-done
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+// A Mode processes the input of a TTY (see SetMode).  Raw, Line and Frame are
+// the built-in modes, and applications can provide their own.
+//
+// Input is called with each chunk read from the console, and it uses the
+// Context to echo to the screen and to emit the chunks which are read from
+// the TTY.  Input is called while the TTY is processing input, so it must not
+// call the methods of the TTY itself; the Context provides what it needs.  The
+// chunks it is given are only valid for the duration of the call.
+//
+// A Mode may also implement Redrawer and Resizer.
+type Mode interface {
+	Input(c *Context, input []byte)
+}
+
+// A Redrawer is a Mode which can redraw the screen, for instance after
+// another program has used the terminal (see Suspend).
+type Redrawer interface {
+	Redraw(c *Context)
+}
+
+// A Resizer is a Mode which is informed when the terminal is resized (see
+// Resize).  The new size is also available from the Context.
+type Resizer interface {
+	Resize(c *Context, width, height int)
+}
+
+// A Context provides access to a TTY for its Mode.  It is only valid for the
+// duration of the call to which it was provided.
+type Context struct {
+	t     *TTY
+	queue bool // Whether Emit queues the chunk for Read (see enqueue)
+}
+
+// Emit sends a chunk to be read from the TTY by itself.  The chunk is copied.
+// Chunks emitted by Input are sent once it returns, and no more input is read
+// until there is room for them in the read buffer (or, for a TTY created with
+// NewFrameTTY, until they are read).  During Resize and Redraw, the chunk is
+// queued to be read without waiting.
+func (c *Context) Emit(chunk []byte) {
+	chunk = append([]byte(nil), chunk...)
+	if c.queue {
+		c.t.enqueue(chunk)
+		return
+	}
+	c.t.send(chunk)
+}
+
+// Echo writes to the screen, if interactive echo is enabled.
+func (c *Context) Echo(b []byte) { c.t.echo(b...) }
+
+// Size returns the size of the terminal, or zeroes if it is not known (see
+// Resize).
+func (c *Context) Size() (width, height int) { return c.t.width, c.t.height }
+
+// The following are the built-in modes; see SetMode.
+var (
+	Raw   Mode = rawMode{}   // All reads are passed through
+	Line  Mode = lineMode{}  // Basic line-editing capabilities are provided
+	Frame Mode = frameMode{} // Basic screen-editing capabilities are provided
+)
+
+// rawMode is the Mode of Raw.
+type rawMode struct{}
+
+func (rawMode) Input(c *Context, input []byte) { c.Emit(input) }
+
+// lineMode is the Mode of Line.
+type lineMode struct{}
+
+func (lineMode) Input(c *Context, input []byte) { c.t.lineInput(input) }
+func (lineMode) Redraw(c *Context)              { c.t.redrawLine() }
+func (lineMode) Resize(c *Context, _, _ int)    { c.t.rewrap() }
+
+// frameMode is the Mode of Frame.
+type frameMode struct{}
+
+func (frameMode) Input(c *Context, input []byte) { c.t.lineInput(input) }
+func (frameMode) Redraw(c *Context)              { c.t.redrawFrame() }
+func (frameMode) Resize(c *Context, w, h int) {
+	c.t.resizeFrame()
+	c.Emit(resizeEvent(w, h))
+}
@@ -0,0 +1,90 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"testing"
+)
+
+// HexMode is a Mode which reads bytes as pairs of hex digits.
+type HexMode struct {
+	digits []byte
+}
+
+func (h *HexMode) Input(c *Context, input []byte) {
+	for _, ch := range input {
+		if _, err := strconv.ParseUint(string(ch), 16, 8); err != nil {
+			continue
+		}
+		c.Echo(bytes.ToUpper([]byte{ch}))
+		if h.digits = append(h.digits, ch); len(h.digits) == 2 {
+			b, _ := strconv.ParseUint(string(h.digits), 16, 8)
+			c.Emit([]byte{byte(b)})
+			h.digits = h.digits[:0]
+		}
+	}
+}
+
+func (h *HexMode) Redraw(c *Context) { c.Echo([]byte("*")) }
+
+func (h *HexMode) Resize(c *Context, width, height int) {
+	if w, h := c.Size(); w != width || h != height {
+		panic("Size does not match Resize")
+	}
+	c.Emit([]byte(fmt.Sprintf("%dx%d", width, height)))
+}
+
+func TestMode(t *testing.T) {
+	pipe := NewDoublePipe()
+	defer pipe.Local.Close()
+	tty := NewTTY(pipe.Remote)
+	tty.SetTerminal(new(FakeTerminal))
+	tty.SetMode(new(HexMode))
+
+	raw := make([]byte, 4096)
+	expect := func(what string, r io.Reader, want string) {
+		n, err := r.Read(raw)
+		if err != nil {
+			t.Fatalf("%s: %s", what, err)
+		}
+		if got := string(raw[:n]); got != want {
+			t.Errorf("%s = %q, want %q", what, got, want)
+		}
+	}
+
+	io.WriteString(pipe.Local, "4ax6")
+	expect("echo", pipe.Local, "4")
+	expect("echo", pipe.Local, "A")
+	expect("echo", pipe.Local, "6") // Chunks are sent once the input is processed
+	expect("read", tty, "J")
+
+	tty.Resize(80, 24)
+	expect("read", tty, "80x24")
+
+	go tty.Suspend(func() error { return nil })
+	expect("echo", pipe.Local, "*")
+
+	io.WriteString(pipe.Local, "9")
+	expect("echo", pipe.Local, "9")
+	expect("read", tty, "i")
+
+	tty.SetMode(Raw)
+	io.WriteString(pipe.Local, "\x03x")
+	expect("read", tty, "\x03x")
+}
@@ -0,0 +1,63 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"io"
+	"time"
+)
+
+// An interrupter reads from the console in a way that Suspend can interrupt.
+// If interrupt is nil, reads cannot be interrupted.
+type interrupter struct {
+	read      func(b []byte) (int, error) // Reads from the console
+	interrupt func() error                // Makes pending and future reads return errInterrupted
+	resume    func() error                // Allows reads to continue after interrupt
+	close     func()                      // Releases any resources (if not nil)
+}
+
+// errInterrupted is returned by an interrupted read.  Like the error of a read
+// which passed its deadline, it is a timeout.
+var errInterrupted error = interrupted{}
+
+type interrupted struct{}
+
+func (interrupted) Error() string { return "read interrupted" }
+func (interrupted) Timeout() bool { return true }
+
+// A deadliner is a console whose pending reads can be interrupted.
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// newInterrupter returns an interrupter for the console.
+//
+// If the console supports read deadlines (as do a net.Conn and an *os.File
+// opened on a pipe), they are used.  Otherwise, if the console has a file
+// descriptor (such as a terminal device) and the operating system supports
+// it, the interrupter waits for input or for an interrupt before reading.
+func newInterrupter(console io.Reader) *interrupter {
+	if d, ok := console.(deadliner); ok && d.SetReadDeadline(time.Time{}) == nil {
+		return &interrupter{
+			read:      console.Read,
+			interrupt: func() error { return d.SetReadDeadline(time.Now()) },
+			resume:    func() error { return d.SetReadDeadline(time.Time{}) },
+		}
+	}
+	if in := fdInterrupter(console); in != nil {
+		return in
+	}
+	return &interrupter{read: console.Read}
+}
@@ -0,0 +1,26 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"syscall"
+)
+
+// selectRead waits until one of the file descriptors in the set is readable,
+// leaving only those which are in the set.
+func selectRead(nfd int, set *syscall.FdSet) error {
+	_, err := syscall.Select(nfd, set, nil, nil, nil)
+	return err
+}
@@ -0,0 +1,97 @@
+// +build linux darwin
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"io"
+	"syscall"
+	"unsafe"
+)
+
+// fdInterrupter returns an interrupter which waits with select(2) until the
+// console's file descriptor is readable or the interrupt pipe is written.  It
+// does not change the file descriptor, so programs run by Suspend can read
+// from the terminal normally.  If the console has no file descriptor, nil is
+// returned.
+func fdInterrupter(console io.Reader) *interrupter {
+	f, ok := console.(interface{ Fd() uintptr })
+	if !ok {
+		return nil
+	}
+	fd := int(f.Fd())
+
+	var wake [2]int
+	if err := syscall.Pipe(wake[:]); err != nil {
+		return nil
+	}
+	syscall.CloseOnExec(wake[0])
+	syscall.CloseOnExec(wake[1])
+	if fd >= fdSetSize || wake[0] >= fdSetSize {
+		syscall.Close(wake[0])
+		syscall.Close(wake[1])
+		return nil
+	}
+
+	nfd := fd + 1
+	if wake[0] >= nfd {
+		nfd = wake[0] + 1
+	}
+	read := func(b []byte) (int, error) {
+		for {
+			var set syscall.FdSet
+			fdSet(&set, fd)
+			fdSet(&set, wake[0])
+			if err := selectRead(nfd, &set); err == syscall.EINTR {
+				continue
+			} else if err != nil {
+				return 0, err
+			}
+			if fdIsSet(&set, wake[0]) {
+				var drain [16]byte
+				syscall.Read(wake[0], drain[:])
+				return 0, errInterrupted
+			}
+			return console.Read(b)
+		}
+	}
+	return &interrupter{
+		read: read,
+		interrupt: func() error {
+			_, err := syscall.Write(wake[1], []byte{0})
+			return err
+		},
+		resume: func() error { return nil },
+		close: func() {
+			syscall.Close(wake[0])
+			syscall.Close(wake[1])
+		},
+	}
+}
+
+// fdSetSize is the number of file descriptors which fit in a syscall.FdSet.
+const fdSetSize = 8 * int(unsafe.Sizeof(syscall.FdSet{}))
+
+// fdBits is the number of file descriptors in each word of a syscall.FdSet.
+const fdBits = 8 * int(unsafe.Sizeof(syscall.FdSet{}.Bits[0]))
+
+func fdSet(set *syscall.FdSet, fd int) {
+	set.Bits[fd/fdBits] |= 1 << uint(fd%fdBits)
+}
+
+func fdIsSet(set *syscall.FdSet, fd int) bool {
+	return set.Bits[fd/fdBits]&(1<<uint(fd%fdBits)) != 0
+}
@@ -0,0 +1,173 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"fmt"
+)
+
+// Resize informs the TTY that the terminal is now the given number of columns
+// wide and rows high.  It is intended to be called whenever the terminal is
+// resized, for instance by a *termios.TermSettings:
+//   tio.WatchSize(tty.Resize)
+//
+// In Line mode, if a line is being entered, the prompt (see SetPrompt) and the
+// line are redrawn so that they wrap correctly at the new width.
+//
+// In Frame mode, the resize callback of each region (see OnResize) is called,
+// the screen is cleared and all regions are redrawn (their contents are
+// cleared, as the screen buffer is replaced by one of the new size).  A Resize
+// event is then read from the TTY as a chunk of its own, which can be decoded
+// with ParseResize.
+//
+// In other modes, the mode is informed if it is a Resizer.  Chunks which it
+// emits are queued to be read, so Resize does not wait for them to be read.
+func (t *TTY) Resize(width, height int) {
+	t.state.Lock()
+	t.drawing.Lock()
+	t.width, t.height = width, height
+	t.drawing.Unlock()
+	var callbacks []func(width, height int)
+	if _, ok := t.mode.(frameMode); ok {
+		for _, r := range t.regions {
+			if r.resize != nil {
+				callbacks = append(callbacks, r.resize)
+			}
+		}
+	}
+	t.state.Unlock()
+
+	// The callbacks are called without the lock, so they can use the regions
+	for _, fn := range callbacks {
+		fn(width, height)
+	}
+
+	t.state.Lock()
+	defer t.state.Unlock()
+	if r, ok := t.mode.(Resizer); ok {
+		r.Resize(&Context{t: t, queue: true}, t.width, t.height)
+	}
+}
+
+// SetSize sets the size of the terminal without redrawing anything, for
+// instance to its size when the TTY is created:
+//   if w, h, err := tio.GetSize(); err == nil {
+//       tty.SetSize(w, h)
+//   }
+//
+// In Frame mode, the screen buffer is created at this size, so SetSize should
+// be called before drawing; anything drawn already is discarded if the size
+// changes.  Call Resize when the terminal is resized afterward.
+func (t *TTY) SetSize(width, height int) {
+	t.state.Lock()
+	defer t.state.Unlock()
+	t.drawing.Lock()
+	defer t.drawing.Unlock()
+
+	t.width, t.height = width, height
+	if s := t.cellbuf; s != nil && (s.width != width || s.height != height) {
+		t.cellbuf = nil
+	}
+}
+
+// resizeFrame clears the screen at its new size and redraws the regions.
+//
+// Side effects:
+// - t.cellbuf is replaced
+func (t *TTY) resizeFrame() {
+	t.drawing.Lock()
+	defer t.drawing.Unlock()
+
+	t.cellbuf = nil
+	t.clear()
+	for _, r := range t.regions {
+		r.paint()
+	}
+	t.flush()
+}
+
+// resizeEvent returns the Resize event for the given size (see ParseResize).
+func resizeEvent(width, height int) []byte {
+	return []byte(fmt.Sprintf("\x1b[8;%d;%dt", height, width))
+}
+
+// ParseResize decodes a Resize event read from a TTY in Frame mode.  The event
+// has the same form as the xterm window size report:
+//   ESC [ 8 ; <rows> ; <columns> t
+// If the chunk is not a Resize event, ok is false.
+func ParseResize(chunk []byte) (width, height int, ok bool) {
+	var tail string
+	n, _ := fmt.Sscanf(string(chunk), "\x1b[8;%d;%d%s", &height, &width, &tail)
+	if n != 3 || tail != "t" {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// rewrap redraws the prompt and the current line (like redrawLine) at the current
+// width, assuming that the terminal has rewrapped the line to the new width.
+// If the width is not known, it just calls redrawLine.
+//
+// To echo the line, the following is written:
+//   <CR><up><erase><prompt><line><move>
+// Where <up> moves to the row on which the prompt begins, <erase> clears the
+// screen from there on, and <move> returns the cursor to its position in the
+// line.
+//
+// Side effects:
+// - t.hint has changed
+func (t *TTY) rewrap() {
+	if t.width <= 0 {
+		t.redrawLine()
+		return
+	}
+	if len(t.output) == 0 || t.escaping {
+		return
+	}
+	t.hint = nil
+
+	start := len(t.prompt)
+	cur, end := start+t.cursor(), start+len(t.output)
+
+	// row returns the row (relative to the prompt) of the cursor at pos.  At
+	// the end of a line which exactly fills its last row, the cursor remains
+	// in the margin of that row.
+	row := func(pos int) int {
+		if pos == end && pos > 0 && pos%t.width == 0 {
+			return pos/t.width - 1
+		}
+		return pos / t.width
+	}
+
+	overwrite := make([]byte, 0, 16+len(t.prompt)+len(t.output))
+	overwrite = append(overwrite, '\r')
+	if up := row(cur); up > 0 {
+		overwrite = append(overwrite, fmt.Sprintf("\x1b[%dA", up)...)
+	}
+	overwrite = append(overwrite, ESC, '[', 'J')
+	overwrite = append(overwrite, t.prompt...)
+	overwrite = append(overwrite, t.output...)
+	if cur != end {
+		if up := row(end) - row(cur); up > 0 {
+			overwrite = append(overwrite, fmt.Sprintf("\x1b[%dA", up)...)
+		}
+		overwrite = append(overwrite, '\r')
+		if right := cur % t.width; right > 0 {
+			overwrite = append(overwrite, fmt.Sprintf("\x1b[%dC", right)...)
+		}
+	}
+	t.echo(overwrite...)
+	t.showhint()
+}
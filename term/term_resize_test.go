@@ -0,0 +1,88 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"io"
+	"testing"
+)
+
+func TestResizeLine(t *testing.T) {
+	pipe := NewDoublePipe()
+	tty := NewTTY(pipe.Remote)
+	tty.SetPrompt("> ")
+
+	raw := make([]byte, 4096)
+	expect := func(want ...string) {
+		for _, want := range want {
+			n, err := pipe.Local.Read(raw)
+			if err != nil {
+				t.Fatalf("echo: %s", err)
+			}
+			if got := string(raw[:n]); got != want {
+				t.Errorf("echo = %q, want %q", got, want)
+			}
+		}
+	}
+
+	io.WriteString(pipe.Local, "abcdef")
+	expect("a", "b", "c", "d", "e", "f")
+	go tty.Resize(4, 10)
+	expect("\r\x1b[1A\x1b[J> abcdef")
+
+	io.WriteString(pipe.Local, "\x1b[D\x1b[D")
+	expect("\x1b[D", "\x1b[D")
+	go tty.Resize(5, 10)
+	expect("\r\x1b[1A\x1b[J> abcdef\r\x1b[1C")
+
+	io.WriteString(pipe.Local, "\r")
+	expect("\r\n")
+	if n, err := tty.Read(raw); err != nil || string(raw[:n]) != "abcdef" {
+		t.Errorf("Read = %q, %v; want %q", raw[:n], err, "abcdef")
+	}
+	pipe.Local.Close()
+}
+
+func TestResizeFrame(t *testing.T) {
+	pipe := NewDoublePipe()
+	tty, region := NewFrameTTY(pipe.Remote)
+	region.SetSize(2, 1)
+	region.OnResize(func(width, height int) {
+		region.SetSize(width, height)
+	})
+
+	done := make(chan bool)
+	go VerifyReads(t, "resize frame", "echo", pipe.Local, []string{
+		"\x1b[2J", "\x1b[1;1H",
+	}, done)
+
+	tty.Resize(3, 1) // The event is queued, so this does not wait for Read
+
+	raw := make([]byte, 64)
+	n, err := tty.Read(raw)
+	if err != nil {
+		t.Fatalf("Read: %s", err)
+	}
+	w, h, ok := ParseResize(raw[:n])
+	if !ok || w != 3 || h != 1 {
+		t.Errorf("ParseResize(%q) = %d, %d, %v; want 3, 1, true", raw[:n], w, h, ok)
+	}
+	if _, _, ok := ParseResize([]byte("\x1b[8;1;3")); ok {
+		t.Errorf("ParseResize of a partial event succeeded")
+	}
+
+	pipe.Remote.Close()
+	<-done
+}
@@ -0,0 +1,325 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"fmt"
+)
+
+// The default size of the screen buffer, if the size of the terminal is not
+// known (see SetSize and Resize).
+const (
+	DefaultScreenWidth  = 80
+	DefaultScreenHeight = 24
+)
+
+// A Cell is a single character position on the screen.  Each character is
+// assumed to occupy a single column.
+type Cell struct {
+	Rune  rune
+	Style Style
+}
+
+// blank is the contents of a cell after the screen is cleared.
+var blank = Cell{' ', Style{}}
+
+// A Style describes how the character in a Cell is displayed.  The zero Style
+// is the terminal's default.
+type Style struct {
+	Fg, Bg Color // Foreground and background colors
+	Attr   Attr  // Display attributes
+}
+
+// A Color is a color from the terminal's palette, or the default color.
+type Color uint16
+
+// The following constants are the eight basic colors; use Palette for the
+// others.
+const (
+	DefaultColor Color = iota
+	Black
+	Red
+	Green
+	Yellow
+	Blue
+	Magenta
+	Cyan
+	White
+)
+
+// Palette returns the color at the given index in the terminal's 256-color
+// palette.  The first eight are the basic colors and the next eight are their
+// bright counterparts.
+func Palette(index uint8) Color {
+	return Color(index) + 1
+}
+
+// An Attr is a set of display attributes.
+type Attr uint8
+
+// The following constants are the display attributes, which can be combined.
+const (
+	Bold Attr = 1 << iota
+	Dim
+	Underline
+	Blink
+	Reverse
+)
+
+// sgrAttrs are the SGR parameters for each of the attributes, in order.
+var sgrAttrs = []int{1, 2, 4, 5, 7}
+
+// sgr appends the SGR sequence which sets the style (after resetting to the
+// default style) to out.
+func (s Style) sgr(out []byte) []byte {
+	out = append(out, ESC, '[', '0')
+	for i, n := range sgrAttrs {
+		if s.Attr&(1<<uint(i)) != 0 {
+			out = append(out, fmt.Sprintf(";%d", n)...)
+		}
+	}
+	color := func(c Color, base, bright, extended int) {
+		switch n := int(c) - 1; {
+		case c == DefaultColor:
+		case n < 8:
+			out = append(out, fmt.Sprintf(";%d", base+n)...)
+		case n < 16:
+			out = append(out, fmt.Sprintf(";%d", bright+n-8)...)
+		default:
+			out = append(out, fmt.Sprintf(";%d;5;%d", extended, n)...)
+		}
+	}
+	color(s.Fg, 30, 90, 38)
+	color(s.Bg, 40, 100, 48)
+	return append(out, 'm')
+}
+
+// A screen holds the cells of a Frame TTY.  The back buffer is what is being
+// drawn, and the front buffer is what is believed to be displayed.  A zero
+// Cell in the front buffer is not known to be displayed, so it is always
+// written (the back buffer never holds one, as set stores a zero rune as a
+// space).
+type screen struct {
+	width, height int
+	back, front   []Cell
+	curx, cury    int // Where the cursor is left after a flush
+}
+
+// newScreen returns a new screen with a blank back buffer.  What is displayed
+// is not known, so the first diff writes every cell.
+func newScreen(width, height int) *screen {
+	s := &screen{
+		width:  width,
+		height: height,
+		back:   make([]Cell, width*height),
+		front:  make([]Cell, width*height),
+	}
+	for i := range s.back {
+		s.back[i] = blank
+	}
+	return s
+}
+
+// clear blanks both buffers, as the screen is blanked when it is cleared.
+func (s *screen) clear() {
+	for i := range s.back {
+		s.back[i], s.front[i] = blank, blank
+	}
+}
+
+// set sets the cell in the back buffer, if it is on the screen.  A zero rune
+// is stored as a space.
+func (s *screen) set(x, y int, c Cell) {
+	if x < 0 || y < 0 || x >= s.width || y >= s.height {
+		return
+	}
+	if c.Rune == 0 {
+		c.Rune = ' '
+	}
+	s.back[y*s.width+x] = c
+}
+
+// diff returns the output which makes the displayed screen match the back
+// buffer, and updates the front buffer to match.
+//
+// The cursor is initially positioned absolutely, since echo may have moved it,
+// and relatively afterward whenever that is shorter.  A short run of unchanged
+// cells in the same style is rewritten rather than skipped over.  The style is
+// reset to the default at the end.
+//
+// Side effects:
+// - s.front is the same as s.back
+func (s *screen) diff() []byte {
+	var out []byte
+	x, y := -1, -1 // The cursor position, or -1 if it is not known
+	var style Style
+
+	for i, c := range s.back {
+		if c == s.front[i] {
+			continue
+		}
+		col, row := i%s.width, i/s.width
+		if row == y && col > x && col-x <= 3 && s.same(i-(col-x), i, style) {
+			for _, c := range s.back[i-(col-x) : i] {
+				out = append(out, string(c.Rune)...)
+			}
+		} else {
+			out = move(out, x, y, col, row)
+		}
+		if c.Style != style {
+			out = c.Style.sgr(out)
+			style = c.Style
+		}
+		out = append(out, string(c.Rune)...)
+		s.front[i] = c
+		x, y = col+1, row
+		if x == s.width {
+			// The cursor may or may not have wrapped to the next row
+			x, y = -1, -1
+		}
+	}
+
+	if style != (Style{}) {
+		out = append(out, ESC, '[', '0', 'm')
+	}
+	return move(out, x, y, s.curx, s.cury)
+}
+
+// rows returns the output which displays the cells of the back buffer within
+// the rectangle (clipped to the screen), as an absolute cursor motion followed
+// by the cells of each row, and updates the front buffer to match.
+//
+// Side effects:
+// - s.front is the same as s.back within the rectangle
+func (s *screen) rows(r rect) [][]byte {
+	x0, y0, x1, y1 := r.x, r.y, r.x+r.width, r.y+r.height
+	if x0 < 0 {
+		x0 = 0
+	}
+	if y0 < 0 {
+		y0 = 0
+	}
+	if x1 > s.width {
+		x1 = s.width
+	}
+	if y1 > s.height {
+		y1 = s.height
+	}
+
+	var out [][]byte
+	for y := y0; y < y1 && x0 < x1; y++ {
+		var row []byte
+		var style Style
+		for i := y*s.width + x0; i < y*s.width+x1; i++ {
+			c := s.back[i]
+			if c.Style != style {
+				row = c.Style.sgr(row)
+				style = c.Style
+			}
+			row = append(row, string(c.Rune)...)
+			s.front[i] = c
+		}
+		if style != (Style{}) {
+			row = append(row, ESC, '[', '0', 'm')
+		}
+		out = append(out, move(nil, -1, -1, x0, y), row)
+	}
+	return out
+}
+
+// same reports whether the cells from start to end in the back buffer are
+// unchanged and in the given style.
+func (s *screen) same(start, end int, style Style) bool {
+	for i := start; i < end; i++ {
+		if s.back[i] != s.front[i] || s.back[i].Style != style {
+			return false
+		}
+	}
+	return true
+}
+
+// move appends the shorter of the relative and absolute cursor motions from
+// x,y to tx,ty.  If x is negative, the position is not known, so the absolute
+// motion is used.
+func move(out []byte, x, y, tx, ty int) []byte {
+	abs := fmt.Sprintf("\x1b[%d;%dH", ty+1, tx+1)
+	if x < 0 {
+		return append(out, abs...)
+	}
+
+	csi := func(n int, final byte) string {
+		if n == 1 {
+			return string([]byte{ESC, '[', final})
+		}
+		return fmt.Sprintf("\x1b[%d%c", n, final)
+	}
+	var rel string
+	switch {
+	case ty < y:
+		rel += csi(y-ty, 'A')
+	case ty > y:
+		rel += csi(ty-y, 'B')
+	}
+	switch {
+	case tx == 0 && x > 0:
+		rel += "\r"
+	case tx == x-1:
+		rel += "\b"
+	case tx < x:
+		rel += csi(x-tx, 'D')
+	case tx > x:
+		rel += csi(tx-x, 'C')
+	}
+
+	if len(rel) < len(abs) {
+		return append(out, rel...)
+	}
+	return append(out, abs...)
+}
+
+// cells returns the screen of the TTY, creating it at the size of the terminal
+// (or the default size) if necessary.  The caller must hold t.drawing.
+func (t *TTY) cells() *screen {
+	if t.cellbuf == nil {
+		width, height := t.width, t.height
+		if width <= 0 || height <= 0 {
+			width, height = DefaultScreenWidth, DefaultScreenHeight
+		}
+		t.cellbuf = newScreen(width, height)
+	}
+	return t.cellbuf
+}
+
+// Flush updates the screen to match what has been drawn (see Region).  Only
+// the cells which have changed since the last Flush are written, in a single
+// write, and then the cursor is returned to where it was last placed with
+// SetCursor.
+//
+// Flush assumes that nothing else has changed the screen since the last Flush.
+// Characters echoed while typing in Frame mode are not known to it, so they
+// are only overwritten when those cells are drawn again.  After something else
+// has drawn on the screen, call Clear and draw again.
+func (t *TTY) Flush() {
+	t.drawing.Lock()
+	defer t.drawing.Unlock()
+	t.flush()
+}
+
+// flush is Flush, for a caller which holds t.drawing.
+func (t *TTY) flush() {
+	if out := t.cells().diff(); len(out) > 0 {
+		t.echo(out...)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"testing"
+)
+
+var moveTests = []struct {
+	X, Y, TX, TY int
+	Output       string
+}{
+	{-1, -1, 4, 2, "\x1b[3;5H"},
+	{3, 2, 3, 2, ""},
+	{3, 2, 0, 2, "\r"},
+	{3, 2, 2, 2, "\b"},
+	{9, 2, 2, 2, "\x1b[7D"},
+	{3, 2, 4, 2, "\x1b[C"},
+	{3, 2, 0, 3, "\x1b[B\r"},
+	{3, 2, 3, 0, "\x1b[2A"},
+	{30, 20, 1, 1, "\x1b[2;2H"},
+}
+
+func TestMove(t *testing.T) {
+	for _, test := range moveTests {
+		if got, want := string(move(nil, test.X, test.Y, test.TX, test.TY)), test.Output; got != want {
+			t.Errorf("move(%d,%d -> %d,%d) = %q, want %q",
+				test.X, test.Y, test.TX, test.TY, got, want)
+		}
+	}
+}
+
+var sgrTests = []struct {
+	Style  Style
+	Output string
+}{
+	{Style{}, "\x1b[0m"},
+	{Style{Attr: Bold | Reverse}, "\x1b[0;1;7m"},
+	{Style{Fg: Red, Bg: Black}, "\x1b[0;31;40m"},
+	{Style{Fg: Palette(9), Bg: Palette(208), Attr: Underline}, "\x1b[0;4;91;48;5;208m"},
+}
+
+func TestSGR(t *testing.T) {
+	for _, test := range sgrTests {
+		if got, want := string(test.Style.sgr(nil)), test.Output; got != want {
+			t.Errorf("%+v.sgr() = %q, want %q", test.Style, got, want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	s := newScreen(6, 3)
+	draw := func(x, y int, text string, style Style) {
+		for _, ch := range text {
+			s.set(x, y, Cell{ch, style})
+			x++
+		}
+	}
+	bold := Style{Attr: Bold}
+
+	steps := []struct {
+		Desc   string
+		Draw   func()
+		Output string
+	}{
+		{
+			Desc:   "unknown",
+			Draw:   func() {},
+			Output: "\x1b[1;1H      \x1b[2;1H      \x1b[3;1H      \x1b[1;1H",
+		},
+		{
+			Desc:   "nothing",
+			Draw:   func() {},
+			Output: "\x1b[1;1H",
+		},
+		{
+			Desc: "text",
+			Draw: func() {
+				draw(0, 0, "ab", Style{})
+				draw(1, 1, "c", bold)
+				s.curx, s.cury = 2, 1
+			},
+			Output: "\x1b[1;1Hab\x1b[B\b\x1b[0;1mc\x1b[0m",
+		},
+		{
+			Desc:   "unchanged",
+			Draw:   func() { draw(0, 0, "ab", Style{}) },
+			Output: "\x1b[2;3H",
+		},
+		{
+			Desc:   "gap",
+			Draw:   func() { draw(0, 0, "x", Style{}); draw(3, 0, "y", Style{}) },
+			Output: "\x1b[1;1Hxb y\x1b[2;3H",
+		},
+		{
+			Desc:   "margin",
+			Draw:   func() { draw(5, 2, "z", Style{}); s.curx, s.cury = 0, 0 },
+			Output: "\x1b[3;6Hz\x1b[1;1H",
+		},
+	}
+	for _, step := range steps {
+		step.Draw()
+		if got, want := string(s.diff()), step.Output; got != want {
+			t.Errorf("%s: diff() = %q, want %q", step.Desc, got, want)
+		}
+		for i := range s.back {
+			if s.back[i] != s.front[i] {
+				t.Errorf("%s: cell %d = %v after diff, want %v", step.Desc, i, s.front[i], s.back[i])
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"bytes"
+)
+
+// Escape sequences used to display suggestions
+var (
+	dim   = []byte("\x1b[2m")
+	reset = []byte("\x1b[0m")
+)
+
+// suggestion returns the remainder of the most recent history entry which
+// begins with line (ignoring case if t.foldcase is set), or nil if there is no
+// such entry.
+func (t *TTY) suggestion(line []byte) []byte {
+	if len(line) == 0 {
+		return nil
+	}
+	for i := len(t.history) - 1; i >= 0; i-- {
+		prev := t.history[i]
+		if len(prev) <= len(line) {
+			continue
+		}
+		if bytes.HasPrefix(prev, line) || t.foldcase && bytes.EqualFold(prev[:len(line)], line) {
+			return prev[len(line):]
+		}
+	}
+	return nil
+}
+
+// hinted returns the suggestion for the current line, if one should be
+// displayed: suggestions are enabled, interactive echo is enabled, the cursor
+// is at the end of the line, and no escape sequence is in progress.
+func (t *TTY) hinted() []byte {
+	if !t.suggest || t.screen == nil || t.linepos >= 0 || t.escaping {
+		return nil
+	}
+	return t.suggestion(t.output)
+}
+
+// showhint displays the suggestion for the current line after the cursor, if
+// there is one and no hint is already displayed.
+//
+// To echo the suggestion, the following is written:
+//   <dim><hint><reset><backspaces>
+// Where <backspaces> returns the cursor to the end of the line.
+//
+// Side effects:
+// - t.hint contains the displayed suggestion
+func (t *TTY) showhint() {
+	if len(t.hint) > 0 {
+		return
+	}
+	t.hint = t.hinted()
+	if len(t.hint) == 0 {
+		return
+	}
+	overwrite := make([]byte, 0, len(dim)+2*len(t.hint)+len(reset))
+	overwrite = append(overwrite, dim...)
+	overwrite = append(overwrite, t.hint...)
+	overwrite = append(overwrite, reset...)
+	overwrite = append(overwrite, bytes.Repeat([]byte{'\b'}, len(t.hint))...)
+	t.echo(overwrite...)
+}
+
+// clearhint erases the currently displayed suggestion (if any) by writing
+// spaces over it and returning the cursor to the end of the line.
+//
+// Side effects:
+// - t.hint is nil
+func (t *TTY) clearhint() {
+	if len(t.hint) == 0 {
+		return
+	}
+	overwrite := make([]byte, 2*len(t.hint))
+	for i := range t.hint {
+		overwrite[i] = ' '
+		overwrite[len(t.hint)+i] = '\b'
+	}
+	t.hint = nil
+	t.echo(overwrite...)
+}
+
+// accept appends the given part of the suggestion to the line and echoes it.
+//
+// Preconditions:
+// - The cursor is at the end of the line and no hint is displayed
+// Side effects:
+// - t.output has the accepted bytes appended to it
+func (t *TTY) accept(hint []byte) {
+	t.echo(hint...)
+	t.output = append(t.output, hint...)
+}
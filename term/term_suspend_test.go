@@ -0,0 +1,253 @@
+// +build linux darwin
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// FileConsole is a console which reads from a pipe (which supports deadlines)
+// and echoes to a separate writer.
+type FileConsole struct {
+	*os.File
+	echo io.Writer
+}
+
+func (c FileConsole) Write(b []byte) (int, error) { return c.echo.Write(b) }
+
+func TestSuspend(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %s", err)
+	}
+	defer w.Close()
+	echoR, echoW := io.Pipe()
+
+	tty := NewTTY(FileConsole{r, echoW})
+	term := new(FakeTerminal)
+	tty.SetTerminal(term)
+	tty.SetPrompt("> ")
+
+	raw := make([]byte, 4096)
+	expect := func(want string) {
+		n, err := echoR.Read(raw)
+		if err != nil {
+			t.Fatalf("echo: %s", err)
+		}
+		if got := string(raw[:n]); got != want {
+			t.Errorf("echo = %q, want %q", got, want)
+		}
+	}
+
+	io.WriteString(w, "ab")
+	expect("a")
+	expect("b")
+
+	// The child should read what is typed while suspended
+	done := make(chan bool)
+	go func() {
+		expect("\r> ab\x1b[K")
+		done <- true
+	}()
+	child := func() error {
+		io.WriteString(w, "c")
+		conn, err := r.SyscallConn()
+		if err != nil {
+			return err
+		}
+		var n int
+		conn.Control(func(fd uintptr) {
+			n, err = syscall.Read(int(fd), raw)
+		})
+		if n < 0 {
+			n = 0
+		}
+		if got, want := string(raw[:n]), "c"; got != want {
+			t.Errorf("child read %q, want %q", got, want)
+		}
+		return err
+	}
+	if err := tty.Suspend(child); err != nil {
+		t.Errorf("Suspend: %s", err)
+	}
+	<-done
+	if got, want := term.Calls(), "Reset Raw"; got != want {
+		t.Errorf("terminal calls = %q, want %q", got, want)
+	}
+
+	io.WriteString(w, "d\n")
+	expect("d")
+	expect("\r\n")
+	if n, err := tty.Read(raw); err != nil || string(raw[:n]) != "abd" {
+		t.Errorf("Read = %q, %v; want %q", raw[:n], err, "abd")
+	}
+}
+
+func TestSuspendFd(t *testing.T) {
+	// A blocking pipe, like a terminal device, does not support deadlines
+	var fds [2]int
+	if err := syscall.Pipe(fds[:]); err != nil {
+		t.Fatalf("pipe: %s", err)
+	}
+	r := os.NewFile(uintptr(fds[0]), "console")
+	w := os.NewFile(uintptr(fds[1]), "keyboard")
+	defer w.Close()
+	if err := r.SetReadDeadline(time.Time{}); err == nil {
+		t.Fatalf("SetReadDeadline succeeded on a blocking pipe")
+	}
+
+	pipe := NewDoublePipe()
+	defer pipe.Remote.Close()
+	go io.Copy(io.Discard, pipe.Local)
+	tty := NewTTY(FileConsole{r, pipe.Remote})
+	tty.SetTerminal(new(FakeTerminal))
+
+	// The reader is waiting for input, so the child gets what is typed
+	io.WriteString(w, "a\n")
+	raw := make([]byte, 4096)
+	if n, err := tty.Read(raw); err != nil || string(raw[:n]) != "a" {
+		t.Fatalf("Read = %q, %v; want %q", raw[:n], err, "a")
+	}
+	child := func() error {
+		io.WriteString(w, "b")
+		n, err := r.Read(raw)
+		if got, want := string(raw[:n]), "b"; got != want {
+			t.Errorf("child read %q, want %q", got, want)
+		}
+		return err
+	}
+	if err := tty.Suspend(child); err != nil {
+		t.Errorf("Suspend: %s", err)
+	}
+
+	io.WriteString(w, "c\n")
+	for _, want := range []string{"\n", "c", "\n"} {
+		if n, err := tty.Read(raw); err != nil || string(raw[:n]) != want {
+			t.Errorf("Read = %q, %v; want %q", raw[:n], err, want)
+		}
+	}
+}
+
+func TestSuspendFrame(t *testing.T) {
+	pipe := NewDoublePipe()
+	tty, region := NewFrameTTY(pipe.Remote)
+	tty.SetTerminal(new(FakeTerminal))
+	region.SetSize(2, 1)
+	region.Print(0, 0, "ab", Style{})
+
+	// What has been drawn is restored, even if it was never flushed
+	done := make(chan bool)
+	go VerifyReads(t, "suspend frame", "echo", pipe.Local, []string{
+		"\x1b[2J", "\x1b[1;1Hab\r",
+	}, done)
+
+	if err := tty.Suspend(func() error { return nil }); err != nil {
+		t.Errorf("Suspend: %s", err)
+	}
+
+	pipe.Remote.Close()
+	<-done
+	pipe.Local.Close()
+}
+
+func TestSuspendUnread(t *testing.T) {
+	pipe := NewDoublePipe()
+	go io.Copy(ioutil.Discard, pipe.Local)
+	tty, _ := NewFrameTTY(pipe.Remote)
+	tty.SetTerminal(new(FakeTerminal))
+
+	raw := make([]byte, 64)
+	io.WriteString(pipe.Local, "ls\r")
+	if n, err := tty.Read(raw); err != nil || string(raw[:n]) != "ls" {
+		t.Fatalf("Read = %q, %v; want %q", raw[:n], err, "ls")
+	}
+
+	// The "\r" chunk has not been read, but Suspend must not wait for it
+	suspended := make(chan error)
+	go func() { suspended <- tty.Suspend(func() error { return nil }) }()
+	select {
+	case err := <-suspended:
+		if err != nil {
+			t.Errorf("Suspend: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Suspend did not return while a chunk was unread")
+	}
+
+	if n, err := tty.Read(raw); err != nil || string(raw[:n]) != "\r" {
+		t.Errorf("Read = %q, %v; want %q", raw[:n], err, "\r")
+	}
+	pipe.Remote.Close()
+	pipe.Local.Close()
+}
+
+func TestJobControl(t *testing.T) {
+	defer func(stop func() error) { stopProcess = stop }(stopProcess)
+	stopProcess = func() error {
+		// Pretend to be stopped and continued
+		return syscall.Kill(os.Getpid(), syscall.SIGCONT)
+	}
+
+	pipe := NewDoublePipe()
+	tty := NewTTY(pipe.Remote)
+	term := new(FakeTerminal)
+	tty.SetTerminal(term)
+	tty.SetPrompt("> ")
+	tty.SetJobControl(true)
+	defer tty.SetJobControl(false)
+
+	raw := make([]byte, 4096)
+	expect := func(want string) {
+		n, err := pipe.Local.Read(raw)
+		if err != nil {
+			t.Fatalf("echo: %s", err)
+		}
+		if got := string(raw[:n]); got != want {
+			t.Errorf("echo = %q, want %q", got, want)
+		}
+	}
+
+	// Stopped by ^Z
+	go io.WriteString(pipe.Local, "ab\x1a")
+	expect("a")
+	expect("b")
+	expect("\r> ab\x1b[K")
+	if got, want := term.Calls(), "Reset Raw"; got != want {
+		t.Errorf("terminal calls = %q, want %q", got, want)
+	}
+
+	// Stopped by something else
+	syscall.Kill(os.Getpid(), syscall.SIGCONT)
+	expect("\r> ab\x1b[K")
+	if got, want := term.Calls(), "Reset Raw Raw"; got != want {
+		t.Errorf("terminal calls = %q, want %q", got, want)
+	}
+
+	go io.WriteString(pipe.Local, "c\n")
+	expect("c")
+	expect("\r\n")
+	for _, want := range []string{"abc", "\n"} {
+		if n, err := tty.Read(raw); err != nil || string(raw[:n]) != want {
+			t.Errorf("Read = %q, %v; want %q", raw[:n], err, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// +build linux,!mips,!mipsle,!mips64,!mips64le,!ppc64,!ppc64le darwin,cgo linux,cgo
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"github.com/kylelemons/goat/termios"
+)
+
+// DisciplineFrom returns the line discipline of the original settings of a
+// terminal (those which Reset restores), for use with SetDiscipline.  The
+// original settings are used because they are the ones the user configured;
+// the current settings are usually raw.
+//
+// As in the kernel, the signal characters are only used if ISIG is set, the
+// editing characters if ICANON is set, and the word erase character if IEXTEN
+// is set as well.  Characters which do not fit in a byte are disabled.
+func DisciplineFrom(tio *termios.TermSettings) *Discipline {
+	orig := tio.Original()
+	char := func(ch rune) byte {
+		if ch != termios.Disabled && ch < 0x100 {
+			return byte(ch)
+		}
+		return 0
+	}
+
+	d := &Discipline{
+		MapCRtoNL:   orig.Has(termios.ICRNL),
+		MapNLtoCR:   orig.Has(termios.INLCR),
+		IgnoreCR:    orig.Has(termios.IGNCR),
+		EchoControl: orig.Has(termios.ECHO) && orig.Has(termios.ECHOCTL),
+	}
+	if orig.Has(termios.ISIG) {
+		d.Interrupt = char(orig.Char(termios.VINTR))
+		d.Quit = char(orig.Char(termios.VQUIT))
+		d.Suspend = char(orig.Char(termios.VSUSP))
+	}
+	if orig.Has(termios.ICANON) {
+		d.Erase = char(orig.Char(termios.VERASE))
+		d.Kill = char(orig.Char(termios.VKILL))
+		d.EOF = char(orig.Char(termios.VEOF))
+		if orig.Has(termios.IEXTEN) {
+			d.WordErase = char(orig.Char(termios.VWERASE))
+		}
+	}
+	return d
+}
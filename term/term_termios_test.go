@@ -0,0 +1,82 @@
+// +build linux,!mips,!mipsle,!mips64,!mips64le,!ppc64,!ppc64le darwin,cgo linux,cgo
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"testing"
+
+	"github.com/kylelemons/goat/pty"
+	"github.com/kylelemons/goat/termios"
+)
+
+func TestDisciplineFrom(t *testing.T) {
+	master, slave, err := pty.Open()
+	if err != nil {
+		t.Skipf("no ptys: %s", err)
+	}
+	defer master.Close()
+	defer slave.Close()
+
+	tio, err := termios.NewTermSettings(int(slave.Fd()))
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+	if err := tio.Sane(); err != nil {
+		t.Fatalf("Sane: %s", err)
+	}
+	tio.SetChar(termios.VERASE, 'H'&0x1F)
+	tio.DisableChar(termios.VQUIT)
+	if err := tio.Apply(); err != nil {
+		t.Fatalf("Apply: %s", err)
+	}
+
+	// The discipline is taken from the original settings, even once raw
+	user, err := termios.NewTermSettings(int(slave.Fd()))
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+	if err := user.Raw(); err != nil {
+		t.Fatalf("Raw: %s", err)
+	}
+
+	want := Discipline{
+		Erase:       BS,
+		Kill:        NAK,
+		WordErase:   ETB,
+		EOF:         EOT,
+		Interrupt:   ETX,
+		Suspend:     SUB,
+		MapCRtoNL:   true,
+		EchoControl: true,
+	}
+	if got := DisciplineFrom(user); *got != want {
+		t.Errorf("DisciplineFrom = %+v, want %+v", *got, want)
+	}
+
+	tio.Disable(termios.ISIG)
+	if err := tio.Apply(); err != nil {
+		t.Fatalf("Apply: %s", err)
+	}
+	user, err = termios.NewTermSettings(int(slave.Fd()))
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+	want.Interrupt, want.Suspend = 0, 0
+	if got := DisciplineFrom(user); *got != want {
+		t.Errorf("without ISIG, DisciplineFrom = %+v, want %+v", *got, want)
+	}
+}
@@ -0,0 +1,119 @@
+// +build linux darwin
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// GuardSignals are the signals for which a Guard restores the terminal.
+var GuardSignals = []os.Signal{
+	syscall.SIGHUP,
+	syscall.SIGINT,
+	syscall.SIGQUIT,
+	syscall.SIGTERM,
+}
+
+// A Guard restores the original settings of a terminal when the program is
+// terminated by a signal, and makes it easy to do so when it panics or exits.
+// The settings are restored at most once.
+//
+// A deferred call to Close at the top of main covers the main goroutine
+// (including panics).  Since a panic in another goroutine terminates the
+// program without running the deferred calls in main, goroutines should begin
+// with a deferred call to Recover.  Since os.Exit (and thus log.Fatal) does
+// not run deferred calls either, use Exit instead.
+type Guard struct {
+	tio  *TermSettings
+	once sync.Once
+	err  error
+
+	sigs chan os.Signal
+	quit chan bool
+}
+
+// Guard returns a new Guard for the original settings of the terminal.  It
+// immediately begins watching for the signals in GuardSignals.  When one
+// arrives, the settings are restored and the signal is raised again with its
+// default behavior, so the program terminates with the expected status.
+func (tio *TermSettings) Guard() *Guard {
+	g := &Guard{
+		tio:  tio,
+		sigs: make(chan os.Signal, 1),
+		quit: make(chan bool),
+	}
+	signal.Notify(g.sigs, GuardSignals...)
+	go g.watch()
+	return g
+}
+
+// watch waits for a signal (or for the Guard to be closed).
+func (g *Guard) watch() {
+	select {
+	case sig := <-g.sigs:
+		g.Restore()
+		signal.Reset(sig)
+		if sig, ok := sig.(syscall.Signal); ok {
+			syscall.Kill(os.Getpid(), sig)
+			os.Exit(128 + int(sig))
+		}
+		os.Exit(1)
+	case <-g.quit:
+	}
+}
+
+// Restore restores the original settings of the terminal, if it has not yet
+// been done.  It returns the error (if any) from the first call.
+func (g *Guard) Restore() error {
+	g.once.Do(func() {
+		g.err = g.tio.restore()
+	})
+	return g.err
+}
+
+// Close stops watching for signals and restores the original settings of the
+// terminal.  It is intended to be deferred in place of a call to Reset.
+func (g *Guard) Close() error {
+	signal.Stop(g.sigs)
+	select {
+	case <-g.quit:
+	default:
+		close(g.quit)
+	}
+	return g.Restore()
+}
+
+// Recover restores the original settings of the terminal if the goroutine is
+// panicking, and then continues to panic.  It must be called directly by a
+// deferred call:
+//   defer guard.Recover()
+func (g *Guard) Recover() {
+	if r := recover(); r != nil {
+		g.Restore()
+		panic(r)
+	}
+}
+
+// Exit restores the original settings of the terminal and exits the program
+// with the given status.
+func (g *Guard) Exit(code int) {
+	g.Restore()
+	os.Exit(code)
+}
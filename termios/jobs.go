@@ -0,0 +1,81 @@
+// +build linux darwin
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// The following allow a shell to control which of its jobs is in the
+// foreground.  A process which is not in the foreground process group of its
+// controlling terminal is sent SIGTTOU when it changes the foreground process
+// group (or the settings of the terminal), so a shell will usually ignore
+// SIGTTOU:
+//   signal.Ignore(syscall.SIGTTOU)
+
+// ForegroundGroup returns the ID of the foreground process group of the
+// terminal (tcgetpgrp).
+func (tio *TermSettings) ForegroundGroup() (int, error) {
+	var pgrp int32
+	if err := ioctl(tio.fd, syscall.TIOCGPGRP, unsafe.Pointer(&pgrp)); err != nil {
+		return 0, err
+	}
+	return int(pgrp), nil
+}
+
+// SetForegroundGroup makes the given process group the foreground process
+// group of the terminal (tcsetpgrp), which must be the controlling terminal of
+// the process.  The process group must be in the same session.  For instance,
+// a shell starts a job in a new process group, gives it the terminal, waits
+// for it to finish or stop, and then takes the terminal back:
+//   tio.SetForegroundGroup(job.Pid)
+//   ...
+//   tio.SetForegroundGroup(syscall.Getpgrp())
+func (tio *TermSettings) SetForegroundGroup(pgrp int) error {
+	id := int32(pgrp)
+	return ioctl(tio.fd, syscall.TIOCSPGRP, unsafe.Pointer(&id))
+}
+
+// Session returns the ID of the session for which the terminal is the
+// controlling terminal (tcgetsid).
+func (tio *TermSettings) Session() (int, error) {
+	if tiocgsid == 0 {
+		pgrp, err := tio.ForegroundGroup()
+		if err != nil {
+			return 0, err
+		}
+		sid, _, errno := syscall.RawSyscall(syscall.SYS_GETSID, uintptr(pgrp), 0, 0)
+		if errno != 0 {
+			return 0, errno
+		}
+		return int(sid), nil
+	}
+	var sid int32
+	if err := ioctl(tio.fd, tiocgsid, unsafe.Pointer(&sid)); err != nil {
+		return 0, err
+	}
+	return int(sid), nil
+}
+
+// Detach gives up the terminal as the controlling terminal of the process
+// (TIOCNOTTY).  If the process is the session leader, the foreground process
+// group is sent SIGHUP and SIGCONT, and every process in the session loses
+// its controlling terminal.
+func (tio *TermSettings) Detach() error {
+	return ioctlValue(tio.fd, syscall.TIOCNOTTY, 0)
+}
@@ -0,0 +1,108 @@
+// +build linux darwin
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+type modemLine int
+
+// Modem control lines
+const (
+	DTR modemLine = syscall.TIOCM_DTR // data terminal ready (output)
+	RTS modemLine = syscall.TIOCM_RTS // request to send (output)
+	CTS modemLine = syscall.TIOCM_CTS // clear to send (input)
+	DSR modemLine = syscall.TIOCM_DSR // data set ready (input)
+	DCD modemLine = syscall.TIOCM_CAR // data carrier detect (input)
+	RI  modemLine = syscall.TIOCM_RNG // ring indicator (input)
+)
+
+var modemLineNames = []struct {
+	line modemLine
+	name string
+}{
+	{DTR, "DTR"},
+	{RTS, "RTS"},
+	{CTS, "CTS"},
+	{DSR, "DSR"},
+	{DCD, "DCD"},
+	{RI, "RI"},
+}
+
+// String returns the names of the lines, separated by |.
+func (m modemLine) String() string {
+	var names []string
+	for _, n := range modemLineNames {
+		if m&n.line != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// ModemLines returns the modem control lines of a serial port which are
+// currently asserted.  For instance, the following checks for a carrier:
+//   lines, err := tio.ModemLines()
+//   if err == nil && lines&DCD != 0 { ... }
+func (tio *TermSettings) ModemLines() (modemLine, error) {
+	var lines int32
+	if err := ioctl(tio.fd, syscall.TIOCMGET, unsafe.Pointer(&lines)); err != nil {
+		return 0, err
+	}
+	return modemLine(lines), nil
+}
+
+// SetModemLines asserts the given output lines (DTR and RTS) and deasserts the
+// others.
+func (tio *TermSettings) SetModemLines(lines modemLine) error {
+	l := int32(lines)
+	return ioctl(tio.fd, syscall.TIOCMSET, unsafe.Pointer(&l))
+}
+
+// RaiseModemLines asserts the given output lines, leaving the others unchanged.
+func (tio *TermSettings) RaiseModemLines(lines modemLine) error {
+	l := int32(lines)
+	return ioctl(tio.fd, syscall.TIOCMBIS, unsafe.Pointer(&l))
+}
+
+// LowerModemLines deasserts the given output lines, leaving the others
+// unchanged.  For instance, the following resets a board which is wired to
+// reset while DTR is low:
+//   tio.LowerModemLines(DTR)
+//   time.Sleep(100 * time.Millisecond)
+//   tio.RaiseModemLines(DTR)
+func (tio *TermSettings) LowerModemLines(lines modemLine) error {
+	l := int32(lines)
+	return ioctl(tio.fd, syscall.TIOCMBIC, unsafe.Pointer(&l))
+}
+
+// errNoModemWait is returned by WaitModemLines where it is not supported.
+var errNoModemWait = errors.New("termios: waiting for modem lines is not supported")
+
+// WaitModemLines waits until any of the given input lines (CTS, DSR, DCD or
+// RI) changes (TIOCMIWAIT).  It is only supported on Linux, and only by some
+// serial drivers.  Use ModemLines to see the new state of the lines.
+func (tio *TermSettings) WaitModemLines(lines modemLine) error {
+	if tiocmiwait == 0 {
+		return errNoModemWait
+	}
+	return ioctlValue(tio.fd, tiocmiwait, int(lines))
+}
@@ -0,0 +1,69 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Run this test with:
+//   go test -c && ./termios.test
+
+package termios
+
+import (
+	"testing"
+)
+
+func TestModemLineString(t *testing.T) {
+	for _, test := range []struct {
+		Lines modemLine
+		Want  string
+	}{
+		{0, ""},
+		{DTR, "DTR"},
+		{DTR | RTS, "DTR|RTS"},
+		{RI | DCD | CTS, "CTS|DCD|RI"},
+	} {
+		if got := test.Lines.String(); got != test.Want {
+			t.Errorf("modemLine(%#x).String() = %q, want %q", int(test.Lines), got, test.Want)
+		}
+	}
+}
+
+func TestModemLines(t *testing.T) {
+	tio, err := NewTermSettings(0)
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+	lines, err := tio.ModemLines()
+	if err != nil {
+		t.Skipf("no modem lines: %s", err)
+	}
+	t.Logf("lines: %s", lines)
+
+	// Asserting the output lines which are already asserted changes nothing
+	out := lines & (DTR | RTS)
+	if err := tio.SetModemLines(out); err != nil {
+		t.Fatalf("SetModemLines(%s): %s", out, err)
+	}
+	if err := tio.RaiseModemLines(out); err != nil {
+		t.Fatalf("RaiseModemLines(%s): %s", out, err)
+	}
+	if err := tio.LowerModemLines(^out & (DTR | RTS)); err != nil {
+		t.Fatalf("LowerModemLines(%s): %s", ^out&(DTR|RTS), err)
+	}
+	got, err := tio.ModemLines()
+	if err != nil {
+		t.Fatalf("ModemLines: %s", err)
+	}
+	if got&(DTR|RTS) != out {
+		t.Errorf("output lines = %q, want %q", got&(DTR|RTS), out)
+	}
+}
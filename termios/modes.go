@@ -0,0 +1,113 @@
+// +build linux darwin
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+import (
+	"errors"
+)
+
+// Cbreak sets the terminal to cbreak mode, in which input is available a
+// character at a time without being echoed, but the signal characters (such
+// as ^C) and output processing (such as mapping NL to CR-NL) still work.
+//
+// The changes are applied immediately.
+func (tio *TermSettings) Cbreak() error {
+	tio.Disable(ICANON, ECHO)
+	tio.Enable(ISIG, OPOST)
+	tio.SetMinRead(1)
+	tio.SetReadTimeout(0)
+	return tio.Apply()
+}
+
+// Cooked sets the terminal to canonical (line-buffered) mode with echo,
+// signal characters and the usual input and output processing, undoing Raw
+// or Cbreak.  It is similar to "stty cooked" (but leaves ISTRIP alone, since
+// it would mangle UTF-8) with echo enabled.  The control characters are not
+// changed; see Sane.
+//
+// The changes are applied immediately.
+func (tio *TermSettings) Cooked() error {
+	tio.Enable(BRKINT, ICRNL, IXON)
+	tio.Enable(OPOST, ONLCR)
+	tio.Enable(CREAD)
+	tio.Enable(ISIG, ICANON, IEXTEN, ECHO, ECHOE, ECHOK)
+	return tio.Apply()
+}
+
+// sane contains the control characters as set by "stty sane".
+var sane = map[charIndex]rune{
+	VINTR:    'C' & 0x1F,
+	VQUIT:    '\\' & 0x1F,
+	VERASE:   0x7F,
+	VKILL:    'U' & 0x1F,
+	VEOF:     'D' & 0x1F,
+	VEOL:     Disabled,
+	VEOL2:    Disabled,
+	VSTART:   'Q' & 0x1F,
+	VSTOP:    'S' & 0x1F,
+	VSUSP:    'Z' & 0x1F,
+	VREPRINT: 'R' & 0x1F,
+	VWERASE:  'W' & 0x1F,
+	VLNEXT:   'V' & 0x1F,
+	VDISCARD: 'O' & 0x1F,
+}
+
+// Sane resets the terminal to reasonable values, like "stty sane": it sets
+// the flags as Cooked does (additionally turning off some unusual processing
+// and delays) and restores the default control characters.  The character
+// size, parity, and speed are not changed.
+//
+// The changes are applied immediately.
+func (tio *TermSettings) Sane() error {
+	tio.Enable(BRKINT, ICRNL, IMAXBEL)
+	tio.Disable(IGNBRK, INLCR, IGNCR, IXOFF, IXANY)
+	tio.Enable(OPOST, ONLCR)
+	tio.Disable(OCRNL, ONOCR, ONLRET, OFILL, OFDEL, NLDLY, CRDLY, TABDLY, BSDLY, VTDLY, FFDLY)
+	tio.Enable(CREAD)
+	tio.Enable(ISIG, ICANON, IEXTEN, ECHO, ECHOE, ECHOK, ECHOCTL, ECHOKE)
+	tio.Disable(ECHONL, NOFLSH, TOSTOP, ECHOPRT)
+	for idx, ch := range sane {
+		tio.SetChar(idx, ch)
+	}
+	tio.SetMinRead(1)
+	tio.SetReadTimeout(0)
+	return tio.Apply()
+}
+
+// errEmptyStack is returned by Pop when there are no saved settings.
+var errEmptyStack = errors.New("termios: Pop without matching Push")
+
+// Push saves the current settings, so that a component can change the mode of
+// the terminal and later return to the mode it was in (rather than to the
+// original settings, as Reset does):
+//   tio.Push()
+//   defer tio.Pop()
+//   tio.Cbreak()
+func (tio *TermSettings) Push() {
+	tio.stack = append(tio.stack, tio.current)
+}
+
+// Pop restores and applies the settings saved by the most recent call to Push.
+func (tio *TermSettings) Pop() error {
+	if len(tio.stack) == 0 {
+		return errEmptyStack
+	}
+	last := len(tio.stack) - 1
+	tio.current = tio.stack[last]
+	tio.stack = tio.stack[:last]
+	return tio.Apply()
+}
@@ -0,0 +1,95 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+import (
+	"testing"
+)
+
+func TestModes(t *testing.T) {
+	tio, err := NewTermSettings(0)
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+	defer tio.Reset()
+
+	// applied returns the settings of the terminal
+	applied := func() *TermSettings {
+		now, err := NewTermSettings(0)
+		if err != nil {
+			t.Fatalf("NewTermSettings: %s", err)
+		}
+		return now
+	}
+
+	if err := tio.Sane(); err != nil {
+		t.Fatalf("Sane: %s", err)
+	}
+	now := applied()
+	for _, f := range []flag{ICANON, ECHO, ISIG, OPOST, ONLCR, ICRNL} {
+		if !now.Has(f) {
+			t.Errorf("after Sane, flag %#o is not set", f)
+		}
+	}
+	if got, want := now.Char(VINTR), rune(3); got != want {
+		t.Errorf("after Sane, VINTR = %d, want %d", got, want)
+	}
+
+	tio.Push()
+	if err := tio.Cbreak(); err != nil {
+		t.Fatalf("Cbreak: %s", err)
+	}
+	now = applied()
+	if now.Has(ICANON) || now.Has(ECHO) || !now.Has(ISIG) || !now.Has(OPOST) {
+		t.Errorf("after Cbreak, flags = %#o %#o", now.Output(), now.Local())
+	}
+
+	tio.Push()
+	if err := tio.Raw(); err != nil {
+		t.Fatalf("Raw: %s", err)
+	}
+	if now = applied(); now.Has(ISIG) {
+		t.Errorf("after Raw, ISIG is set")
+	}
+
+	if err := tio.Pop(); err != nil {
+		t.Fatalf("Pop: %s", err)
+	}
+	if now = applied(); now.Has(ICANON) || !now.Has(ISIG) {
+		t.Errorf("after Pop, not in cbreak mode: %#o", now.Local())
+	}
+	if err := tio.Pop(); err != nil {
+		t.Fatalf("Pop: %s", err)
+	}
+	if now = applied(); !now.Has(ICANON) || !now.Has(ECHO) {
+		t.Errorf("after Pop, not in sane mode: %#o", now.Local())
+	}
+	if err := tio.Pop(); err == nil {
+		t.Errorf("Pop of an empty stack succeeded")
+	}
+
+	if err := tio.Raw(); err != nil {
+		t.Fatalf("Raw: %s", err)
+	}
+	if err := tio.Cooked(); err != nil {
+		t.Fatalf("Cooked: %s", err)
+	}
+	now = applied()
+	for _, f := range []flag{ICANON, ECHO, ISIG, OPOST, ICRNL} {
+		if !now.Has(f) {
+			t.Errorf("after Cooked, flag %#o is not set", f)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// +build linux darwin
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+import (
+	"fmt"
+)
+
+// SetSpeed sets both the input and output speed of the terminal to the given
+// baud rate.  On Linux, any rate can be used (non-standard rates are set with
+// BOTHER); elsewhere, the rate must be supported by the C library.  A rate of
+// zero hangs up the line when the settings are applied.
+//
+// The change is not applied until Apply is called.
+func (tio *TermSettings) SetSpeed(baud int) error {
+	return cfsetspeed(&tio.current, baud)
+}
+
+// Speed returns the output speed of the terminal as a baud rate.
+func (tio *TermSettings) Speed() int {
+	return cfgetspeed(&tio.current)
+}
+
+type (
+	parity      int
+	flowControl int
+)
+
+// Parity settings for SerialConfig
+const (
+	NoParity   parity = iota // no parity bit
+	OddParity                // odd parity (PARENB|PARODD)
+	EvenParity               // even parity (PARENB)
+)
+
+// Flow control settings for SerialConfig
+const (
+	NoFlowControl       flowControl = iota // no flow control
+	HardwareFlowControl                    // RTS/CTS flow control (CRTSCTS)
+	SoftwareFlowControl                    // XON/XOFF flow control (IXON|IXOFF)
+)
+
+// A SerialConfig describes the line settings of a serial port.  The zero value
+// of each field (other than Baud) is the most common setting, so, for example,
+// the following is 9600 8N1:
+//   SerialConfig{Baud: 9600}
+type SerialConfig struct {
+	Baud        int         // Speed in bits per second
+	DataBits    int         // Bits per character: 5, 6, 7 or 8 (0 means 8)
+	Parity      parity      // NoParity, OddParity or EvenParity
+	StopBits    int         // Stop bits: 1 or 2 (0 means 1)
+	FlowControl flowControl // NoFlowControl, HardwareFlowControl or SoftwareFlowControl
+}
+
+// SetSerial puts the terminal into raw mode (see Raw) with the given line
+// settings.  The modem status lines are ignored (CLOCAL) and the receiver is
+// enabled (CREAD), as is usual for a device attached to a serial port.
+//
+// The changes are applied immediately.
+func (tio *TermSettings) SetSerial(cfg SerialConfig) error {
+	sizes := map[int]ctlMode{0: CS8, 5: CS5, 6: CS6, 7: CS7, 8: CS8}
+	parities := map[parity][]flag{
+		NoParity:   nil,
+		OddParity:  {PARENB, PARODD, INPCK},
+		EvenParity: {PARENB, INPCK},
+	}
+	flows := map[flowControl][]flag{
+		NoFlowControl:       nil,
+		HardwareFlowControl: {CRTSCTS},
+		SoftwareFlowControl: {IXON, IXOFF},
+	}
+
+	if cfg.Baud <= 0 {
+		return fmt.Errorf("termios: invalid baud rate %d", cfg.Baud)
+	}
+	size, ok := sizes[cfg.DataBits]
+	if !ok {
+		return fmt.Errorf("termios: invalid data bits %d", cfg.DataBits)
+	}
+	par, ok := parities[cfg.Parity]
+	if !ok {
+		return fmt.Errorf("termios: invalid parity %d", cfg.Parity)
+	}
+	flow, ok := flows[cfg.FlowControl]
+	if !ok {
+		return fmt.Errorf("termios: invalid flow control %d", cfg.FlowControl)
+	}
+	if cfg.StopBits < 0 || cfg.StopBits > 2 {
+		return fmt.Errorf("termios: invalid stop bits %d", cfg.StopBits)
+	}
+
+	settings := tio.current
+	if err := cfsetspeed(&settings, cfg.Baud); err != nil {
+		return err
+	}
+	tio.current = settings
+
+	cfmakeraw(&tio.current)
+	tio.Disable(CSIZE, PARENB, PARODD, CSTOPB, CRTSCTS, IXON, IXOFF, IXANY, INPCK)
+	tio.Enable(size, CLOCAL, CREAD)
+	tio.Enable(par...)
+	tio.Enable(flow...)
+	if cfg.StopBits == 2 {
+		tio.Enable(CSTOPB)
+	}
+	return tio.Apply()
+}
+
+// ConfigureSerial sets up the serial port open on fd with the given settings.
+// It returns the TermSettings for the port, so that its original settings can
+// be restored with Reset.
+func ConfigureSerial(fd int, cfg SerialConfig) (*TermSettings, error) {
+	tio, err := NewTermSettings(fd)
+	if err != nil {
+		return nil, err
+	}
+	if err := tio.SetSerial(cfg); err != nil {
+		return nil, err
+	}
+	return tio, nil
+}
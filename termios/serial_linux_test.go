@@ -0,0 +1,135 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSpeed(t *testing.T) {
+	master, slave := openPty(t)
+	defer master.Close()
+	defer slave.Close()
+
+	for _, baud := range []int{9600, 115200, 4000000, 250000, 31250} {
+		tio, err := NewTermSettings(int(slave.Fd()))
+		if err != nil {
+			t.Fatalf("NewTermSettings: %s", err)
+		}
+		if err := tio.SetSpeed(baud); err != nil {
+			t.Errorf("SetSpeed(%d): %s", baud, err)
+			continue
+		}
+		if err := tio.Apply(); err != nil {
+			t.Errorf("SetSpeed(%d): Apply: %s", baud, err)
+			continue
+		}
+
+		now, err := NewTermSettings(int(slave.Fd()))
+		if err != nil {
+			t.Fatalf("NewTermSettings: %s", err)
+		}
+		if got, want := now.Speed(), baud; got != want {
+			t.Errorf("SetSpeed(%d): Speed() = %d", baud, got)
+		}
+	}
+}
+
+var serialTests = []struct {
+	Config  SerialConfig
+	Set     []flag
+	Cleared []flag
+	Size    ctlMode
+}{
+	{
+		Config:  SerialConfig{Baud: 9600},
+		Set:     []flag{CLOCAL, CREAD},
+		Cleared: []flag{PARENB, CSTOPB, CRTSCTS, IXON, IXOFF, ICANON, ECHO, OPOST},
+		Size:    CS8,
+	},
+	{
+		Config:  SerialConfig{Baud: 19200, DataBits: 7, Parity: EvenParity, StopBits: 2},
+		Set:     []flag{PARENB, INPCK, CSTOPB},
+		Cleared: []flag{PARODD, CRTSCTS},
+		Size:    CS7,
+	},
+	{
+		Config:  SerialConfig{Baud: 250000, Parity: OddParity, FlowControl: HardwareFlowControl},
+		Set:     []flag{PARENB, PARODD, CRTSCTS},
+		Cleared: []flag{CSTOPB, IXON},
+		Size:    CS8,
+	},
+	{
+		Config:  SerialConfig{Baud: 115200, DataBits: 5, FlowControl: SoftwareFlowControl},
+		Set:     []flag{IXON, IXOFF},
+		Cleared: []flag{PARENB, CRTSCTS},
+		Size:    CS5,
+	},
+}
+
+func TestSerial(t *testing.T) {
+	master, slave := openPty(t)
+	defer master.Close()
+	defer slave.Close()
+
+	for _, test := range serialTests {
+		desc := fmt.Sprintf("%+v", test.Config)
+		tio, err := ConfigureSerial(int(slave.Fd()), test.Config)
+		if err != nil {
+			t.Errorf("%s: ConfigureSerial: %s", desc, err)
+			continue
+		}
+
+		now, err := NewTermSettings(int(slave.Fd()))
+		if err != nil {
+			t.Fatalf("NewTermSettings: %s", err)
+		}
+		if got, want := now.Speed(), test.Config.Baud; got != want {
+			t.Errorf("%s: Speed() = %d, want %d", desc, got, want)
+		}
+
+		// The pty always uses CS8 without parity, so check the settings
+		// which were applied as well as the terminal's.
+		if got, want := tio.Control()&CSIZE, test.Size; got != want {
+			t.Errorf("%s: size = %#o, want %#o", desc, got, want)
+		}
+		for _, f := range test.Set {
+			if !tio.Has(f) {
+				t.Errorf("%s: flag %#o is not set", desc, f)
+			}
+			if f != PARENB && !now.Has(f) {
+				t.Errorf("%s: flag %#o is not set on the terminal", desc, f)
+			}
+		}
+		for _, f := range test.Cleared {
+			if tio.Has(f) || now.Has(f) {
+				t.Errorf("%s: flag %#o is set", desc, f)
+			}
+		}
+	}
+
+	for _, cfg := range []SerialConfig{
+		{},
+		{Baud: 9600, DataBits: 9},
+		{Baud: 9600, StopBits: 3},
+		{Baud: 9600, Parity: 7},
+		{Baud: -1},
+	} {
+		if _, err := ConfigureSerial(int(slave.Fd()), cfg); err == nil {
+			t.Errorf("ConfigureSerial(%+v) succeeded", cfg)
+		}
+	}
+}
@@ -15,124 +15,28 @@
 // limitations under the License.
 
 // Package termios implements low-level terminal settings.
+//
+// On Linux, the package is implemented in pure Go (with the TCGETS and TCSETS
+// ioctls), so it can be built with CGO_ENABLED=0.  On other systems, and on
+// Linux architectures whose terminal ABI differs from the generic one, it uses
+// the C library.
 package termios
 
 import (
 	"fmt"
 	"syscall"
+	"time"
 	"unsafe"
 )
 
-/*
-#include <termios.h>
-#include <sys/ioctl.h>
-*/
-import "C"
-
-type (
-	inMode    C.tcflag_t
-	outMode   C.tcflag_t
-	ctlMode   C.tcflag_t
-	locMode   C.tcflag_t
-	charIndex C.cc_t
-)
-
-// Input Flags
-const (
-	IGNBRK  inMode = C.IGNBRK  // ignore BREAK condition
-	BRKINT  inMode = C.BRKINT  // map BREAK to SIGINTR
-	IGNPAR  inMode = C.IGNPAR  // ignore (discard) parity errors
-	PARMRK  inMode = C.PARMRK  // mark parity and framing errors
-	INPCK   inMode = C.INPCK   // enable checking of parity errors
-	ISTRIP  inMode = C.ISTRIP  // strip 8th bit off chars
-	INLCR   inMode = C.INLCR   // map NL into CR
-	IGNCR   inMode = C.IGNCR   // ignore CR
-	ICRNL   inMode = C.ICRNL   // map CR to NL (ala CRMOD)
-	IXON    inMode = C.IXON    // enable output flow control
-	IXOFF   inMode = C.IXOFF   // enable input flow control
-	IXANY   inMode = C.IXANY   // any char will restart after stop
-	IMAXBEL inMode = C.IMAXBEL // ring bell on input queue full
-	IUTF8   inMode = C.IUTF8   // maintain state for UTF-8 VERASE
-)
-
-// Output Flags
-const (
-	OPOST  outMode = C.OPOST  // enable following output processing
-	ONLCR  outMode = C.ONLCR  // map NL to CR-NL (ala CRMOD)
-	OCRNL  outMode = C.OCRNL  // map CR to NL on output
-	ONOCR  outMode = C.ONOCR  // no CR output at column 0
-	ONLRET outMode = C.ONLRET // NL performs CR function
-	OFILL  outMode = C.OFILL  // use fill characters for delay
-	NLDLY  outMode = C.NLDLY  // \n delay
-	TABDLY outMode = C.TABDLY // horizontal tab delay
-	CRDLY  outMode = C.CRDLY  // \r delay
-	FFDLY  outMode = C.FFDLY  // form feed delay
-	BSDLY  outMode = C.BSDLY  // \b delay
-	VTDLY  outMode = C.VTDLY  // vertical tab delay
-	OFDEL  outMode = C.OFDEL  // fill is DEL, else NUL
-)
-
-// Control Flags
-const (
-	CSIZE  ctlMode = C.CSIZE  // character size mask
-	CS6    ctlMode = C.CS6    // 6 bits
-	CS7    ctlMode = C.CS7    // 7 bits
-	CS8    ctlMode = C.CS8    // 8 bits
-	CSTOPB ctlMode = C.CSTOPB // send 2 stop bits
-	CREAD  ctlMode = C.CREAD  // enable receiver
-	PARENB ctlMode = C.PARENB // parity enable
-	PARODD ctlMode = C.PARODD // odd parity, else even
-	HUPCL  ctlMode = C.HUPCL  // hang up on last close
-	CLOCAL ctlMode = C.CLOCAL // ignore modem status lines
-)
-
-// Local flags
-const (
-	ECHOKE  locMode = C.ECHOKE  // visual erase for line kill
-	ECHOE   locMode = C.ECHOE   // visually erase chars
-	ECHOK   locMode = C.ECHOK   // echo NL after line kill
-	ECHO    locMode = C.ECHO    // enable echoing
-	ECHONL  locMode = C.ECHONL  // echo NL even if ECHO is off
-	ECHOPRT locMode = C.ECHOPRT // visual erase mode for hardcopy
-	ECHOCTL locMode = C.ECHOCTL // echo control chars as ^(Char)
-	ISIG    locMode = C.ISIG    // enable signals INTR, QUIT, [D]SUSP
-	ICANON  locMode = C.ICANON  // canonicalize input lines
-	IEXTEN  locMode = C.IEXTEN  // enable DISCARD and LNEXT
-	EXTPROC locMode = C.EXTPROC // external processing
-	TOSTOP  locMode = C.TOSTOP  // stop background jobs from output
-	FLUSHO  locMode = C.FLUSHO  // output being flushed (state)
-	PENDIN  locMode = C.PENDIN  // XXX retype pending input (state)
-	NOFLSH  locMode = C.NOFLSH  // don't flush after interrupt
-)
-
-// Control Character Indices
-const (
-	VEOF     charIndex = C.VEOF     // ICANON
-	VEOL     charIndex = C.VEOL     // ICANON
-	VEOL2    charIndex = C.VEOL2    // ICANON together with IEXTEN
-	VERASE   charIndex = C.VERASE   // ICANON
-	VWERASE  charIndex = C.VWERASE  // ICANON together with IEXTEN
-	VKILL    charIndex = C.VKILL    // ICANON
-	VREPRINT charIndex = C.VREPRINT // ICANON together with IEXTEN
-	VINTR    charIndex = C.VINTR    // ISIG
-	VQUIT    charIndex = C.VQUIT    // ISIG
-	VSUSP    charIndex = C.VSUSP    // ISIG
-	VSTART   charIndex = C.VSTART   // IXON, IXOFF
-	VSTOP    charIndex = C.VSTOP    // IXON, IXOFF
-	VLNEXT   charIndex = C.VLNEXT   // IEXTEN
-	VDISCARD charIndex = C.VDISCARD // IEXTEN
-	VMIN     charIndex = C.VMIN     // !ICANON
-	VTIME    charIndex = C.VTIME    // !ICANON
-	NCC      charIndex = C.NCCS     // Number of control chars
-)
-
 // TermSettings contain both the original settings from when it was created
 // and the current settings being manipulated.  At any time, Reset will
 // restore the terminal to its original state.
 type TermSettings struct {
 	fd       int
-	original C.struct_termios
-	current  C.struct_termios
+	original syscall.Termios
+	current  syscall.Termios
+	stack    []syscall.Termios // Settings saved by Push
 }
 
 // NewTermSettings examines the state of the current terminal and
@@ -140,8 +44,8 @@ type TermSettings struct {
 func NewTermSettings(fd int) (*TermSettings, error) {
 	tio := &TermSettings{fd: fd}
 
-	if ret, errno := C.tcgetattr(C.int(fd), &tio.current); ret != 0 {
-		return nil, errno
+	if err := tcgetattr(fd, &tio.current); err != nil {
+		return nil, err
 	}
 	tio.original = tio.current
 	return tio, nil
@@ -150,32 +54,119 @@ func NewTermSettings(fd int) (*TermSettings, error) {
 // Char returns the rune associated with the given control
 // character.  These will generally be ASCII control characters.
 func (tio *TermSettings) Char(idx charIndex) rune {
-	return rune(tio.current.c_cc[int(idx)])
+	return rune(tio.current.Cc[int(idx)])
+}
+
+// Disabled is the value of a control character which has been disabled
+// (_POSIX_VDISABLE).  The terminal does not treat any input as that character.
+const Disabled rune = vdisable
+
+// SetChar sets the control character at the given index, which must be a
+// single byte.  The change is not applied until Apply is called.
+func (tio *TermSettings) SetChar(idx charIndex, ch rune) {
+	tio.current.Cc[int(idx)] = uint8(ch)
+}
+
+// DisableChar disables the control characters at the given indices, so that no
+// input is treated as them.  For instance, the following keeps ^\ from sending
+// SIGQUIT while leaving the other signal characters enabled:
+//   tio.DisableChar(VQUIT)
+//
+// The change is not applied until Apply is called.
+func (tio *TermSettings) DisableChar(idx ...charIndex) {
+	for _, idx := range idx {
+		tio.SetChar(idx, Disabled)
+	}
+}
+
+// SetMinRead sets the minimum number of bytes (VMIN) for a read to return in
+// non-canonical mode.  It must be between 0 and 255.  The change is not
+// applied until Apply is called.
+func (tio *TermSettings) SetMinRead(n int) {
+	tio.current.Cc[VMIN] = uint8(n)
+}
+
+// MinRead returns the minimum number of bytes (VMIN) for a read to return in
+// non-canonical mode.
+func (tio *TermSettings) MinRead() int {
+	return int(tio.current.Cc[VMIN])
+}
+
+// SetReadTimeout sets the read timeout (VTIME) in non-canonical mode.  The
+// terminal measures it in tenths of a second, so the timeout is rounded up to
+// the nearest tenth (and at most 25.5 seconds).  Together with SetMinRead:
+//   min=0, timeout=0: reads return immediately with whatever is available
+//   min>0, timeout=0: reads wait until min bytes are available
+//   min=0, timeout>0: reads wait up to timeout for a single byte
+//   min>0, timeout>0: after the first byte, reads wait up to timeout between
+//                     bytes for up to min bytes
+// The change is not applied until Apply is called.
+func (tio *TermSettings) SetReadTimeout(timeout time.Duration) {
+	tenths := (timeout + time.Second/10 - 1) / (time.Second / 10)
+	if tenths < 0 {
+		tenths = 0
+	}
+	if tenths > 255 {
+		tenths = 255
+	}
+	tio.current.Cc[VTIME] = uint8(tenths)
 }
 
-// String returns a debugging string which contains low-level
-// information about the terminal.
+// ReadTimeout returns the read timeout (VTIME) in non-canonical mode.
+func (tio *TermSettings) ReadTimeout() time.Duration {
+	return time.Duration(tio.current.Cc[VTIME]) * time.Second / 10
+}
+
+// String returns a debugging string which contains the current settings of
+// the terminal in a readable form, similar to "stty -a".
 func (tio *TermSettings) String() string {
+	size := sizeNames[tio.Control()&CSIZE]
 	return fmt.Sprintf(`Terminal[%d]:
-  Input   = 0x%X
-  Output  = 0x%X
-  Control = 0x%X
-  Local   = 0x%X
-  Chars   = %v
+  Speed   = %d
+  Input   = %s
+  Output  = %s
+  Control = %s %s
+  Local   = %s
+  Chars   = %s
 `,
 		tio.fd,
-		tio.current.c_iflag,
-		tio.current.c_oflag,
-		tio.current.c_cflag,
-		tio.current.c_lflag,
-		tio.current.c_cc)
+		tio.Speed(),
+		flagString(inputNames, tio.current.Iflag),
+		flagString(outputNames, tio.current.Oflag),
+		size, flagString(controlNames, tio.current.Cflag),
+		flagString(localNames, tio.current.Lflag),
+		tio.charsString())
+}
+
+// ioctl performs the given ioctl on fd with a pointer argument.
+func ioctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// ioctlValue performs the given ioctl on fd with an integer argument.
+func ioctlValue(fd int, req uintptr, arg int) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// winsize is the layout of struct winsize used by TIOCGWINSZ and TIOCSWINSZ.
+type winsize struct {
+	row, col       uint16
+	xpixel, ypixel uint16
 }
 
 // GetSize attempts to determine the size of the terminal with which
 // this TermSettings is associated and return the number of rows (the height)
 // and the number of columns (width).
 func (tio *TermSettings) GetSize() (width, height int, err error) {
-	var ws C.struct_winsize
+	var ws winsize
 	_, _, errno := syscall.RawSyscall(syscall.SYS_IOCTL,
 		uintptr(tio.fd),
 		uintptr(syscall.TIOCGWINSZ),
@@ -183,11 +174,36 @@ func (tio *TermSettings) GetSize() (width, height int, err error) {
 	if errno != 0 {
 		return 0, 0, syscall.Errno(errno)
 	}
-	height = int(ws.ws_row)
-	width = int(ws.ws_col)
+	height = int(ws.row)
+	width = int(ws.col)
 	return
 }
 
+// GetSizePixels is like GetSize, but also returns the width and height of the
+// terminal in pixels, if the terminal reports them (otherwise they are zero).
+func (tio *TermSettings) GetSizePixels() (width, height, xpixels, ypixels int, err error) {
+	var ws winsize
+	if err := ioctl(tio.fd, syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil {
+		return 0, 0, 0, 0, err
+	}
+	return int(ws.col), int(ws.row), int(ws.xpixel), int(ws.ypixel), nil
+}
+
+// SetSize sets the size of the terminal to the given number of columns (width)
+// and rows (height), and the given size in pixels (which may be zero if it is
+// not known).  The processes in the foreground process group of the terminal
+// receive SIGWINCH.  This is mostly useful for passing the size of a terminal
+// on to a pseudoterminal.
+func (tio *TermSettings) SetSize(width, height, xpixels, ypixels int) error {
+	ws := winsize{
+		row:    uint16(height),
+		col:    uint16(width),
+		xpixel: uint16(xpixels),
+		ypixel: uint16(ypixels),
+	}
+	return ioctl(tio.fd, syscall.TIOCSWINSZ, unsafe.Pointer(&ws))
+}
+
 // Raw sets the terminal to a very minimal raw mode suitable for simulating a
 // terminal emulator or doing raw line editing.
 //
@@ -200,7 +216,7 @@ func (tio *TermSettings) Raw() error {
 	//tio.SetInput(IGNBRK | IXANY)
 	//tio.SetOutput(0)
 	//tio.SetLocal(0)
-	C.cfmakeraw(&tio.current)
+	cfmakeraw(&tio.current)
 	return tio.Apply()
 }
 
@@ -211,20 +227,137 @@ func (tio *TermSettings) Reset() error {
 	return tio.Apply()
 }
 
-func (tio *TermSettings) SetInput(mode inMode)    { tio.current.c_iflag = C.tcflag_t(mode) }
-func (tio *TermSettings) SetOutput(mode outMode)  { tio.current.c_oflag = C.tcflag_t(mode) }
-func (tio *TermSettings) SetControl(mode ctlMode) { tio.current.c_cflag = C.tcflag_t(mode) }
-func (tio *TermSettings) SetLocal(mode locMode)   { tio.current.c_lflag = C.tcflag_t(mode) }
+// Original returns the settings which were in effect when the call to
+// NewTermSettings was made (those which Reset restores).  Changing them does
+// not change tio.
+func (tio *TermSettings) Original() *TermSettings {
+	return &TermSettings{
+		fd:       tio.fd,
+		original: tio.original,
+		current:  tio.original,
+	}
+}
+
+// restore applies the original settings without changing the current ones.
+func (tio *TermSettings) restore() error {
+	original := tio.original
+	return tcsetattr(tio.fd, tcsanow, &original)
+}
+
+func (tio *TermSettings) SetInput(mode inMode)    { tio.current.Iflag = tcflag(mode) }
+func (tio *TermSettings) SetOutput(mode outMode)  { tio.current.Oflag = tcflag(mode) }
+func (tio *TermSettings) SetControl(mode ctlMode) { tio.current.Cflag = tcflag(mode) }
+func (tio *TermSettings) SetLocal(mode locMode)   { tio.current.Lflag = tcflag(mode) }
+
+func (tio *TermSettings) Input() inMode    { return inMode(tio.current.Iflag) }
+func (tio *TermSettings) Output() outMode  { return outMode(tio.current.Oflag) }
+func (tio *TermSettings) Control() ctlMode { return ctlMode(tio.current.Cflag) }
+func (tio *TermSettings) Local() locMode   { return locMode(tio.current.Lflag) }
+
+// A flag is one of the input, output, control or local flags (or several
+// flags of the same kind combined with |).
+type flag interface {
+	word(t *syscall.Termios) *tcflag // The flag word in t which holds the flag
+	mask() tcflag                    // The bits of the flag
+}
+
+func (m inMode) word(t *syscall.Termios) *tcflag  { return &t.Iflag }
+func (m outMode) word(t *syscall.Termios) *tcflag { return &t.Oflag }
+func (m ctlMode) word(t *syscall.Termios) *tcflag { return &t.Cflag }
+func (m locMode) word(t *syscall.Termios) *tcflag { return &t.Lflag }
+
+func (m inMode) mask() tcflag  { return tcflag(m) }
+func (m outMode) mask() tcflag { return tcflag(m) }
+func (m ctlMode) mask() tcflag { return tcflag(m) }
+func (m locMode) mask() tcflag { return tcflag(m) }
+
+// Has returns true if all of the bits of the given flag are set in the current
+// settings.  For fields of more than one bit, like CSIZE, compare the masked
+// flags instead:
+//   tio.Control()&CSIZE == CS8
+func (tio *TermSettings) Has(f flag) bool {
+	return *f.word(&tio.current)&f.mask() == f.mask()
+}
+
+// Enable sets the given flags in the current settings, leaving the others
+// unchanged.  The flags may be of different kinds.  For instance, the following
+// turns on echoing and the mapping of CR to NL on input:
+//   tio.Enable(ECHO, ICRNL)
+//
+// The changes are not applied until Apply is called.
+func (tio *TermSettings) Enable(flags ...flag) {
+	for _, f := range flags {
+		*f.word(&tio.current) |= f.mask()
+	}
+}
+
+// Disable clears the given flags in the current settings, leaving the others
+// unchanged.  The flags may be of different kinds.  For instance, the following
+// turns off echoing but leaves the signal characters enabled:
+//   tio.Disable(ECHO)
+//
+// The changes are not applied until Apply is called.
+func (tio *TermSettings) Disable(flags ...flag) {
+	for _, f := range flags {
+		*f.word(&tio.current) &^= f.mask()
+	}
+}
 
 // Apply applies the settings currently stored in tio.  This is mostly useful
 // for maintaining multiple TerminalSettings for different modes, and you can
 // simply Apply whichever you need.
 func (tio *TermSettings) Apply() error {
-	const when = C.TCSANOW
-	if ret, errno := C.tcsetattr(C.int(tio.fd), when, &tio.current); ret != 0 {
-		return errno
-	}
-	return nil
+	return tcsetattr(tio.fd, tcsanow, &tio.current)
+}
+
+// ApplyDrain applies the settings currently stored in tio after all output
+// written to the terminal has been transmitted (TCSADRAIN).  This should be
+// used when changing settings which affect output.
+func (tio *TermSettings) ApplyDrain() error {
+	return tcsetattr(tio.fd, tcsadrain, &tio.current)
+}
+
+// ApplyFlush applies the settings currently stored in tio after all output
+// written to the terminal has been transmitted, and discards any input which
+// has been received but not read (TCSAFLUSH).  This keeps typeahead entered
+// in one mode from being read in another.
+func (tio *TermSettings) ApplyFlush() error {
+	return tcsetattr(tio.fd, tcsaflush, &tio.current)
+}
+
+type (
+	queueSelector int
+	flowAction    int
+)
+
+// Flush discards data written to the terminal but not transmitted, or received
+// but not read, or both, depending on queue: TCIFLUSH, TCOFLUSH or TCIOFLUSH.
+func (tio *TermSettings) Flush(queue queueSelector) error {
+	return tcflush(tio.fd, int(queue))
+}
+
+// Drain waits until all output written to the terminal has been transmitted.
+func (tio *TermSettings) Drain() error {
+	return tcdrain(tio.fd)
+}
+
+// Flow suspends or restarts transmission or reception of data, depending on
+// action:
+//   TCOOFF  suspend output
+//   TCOON   restart output
+//   TCIOFF  transmit a STOP character to stop the terminal sending data
+//   TCION   transmit a START character to restart it
+func (tio *TermSettings) Flow(action flowAction) error {
+	return tcflow(tio.fd, int(action))
+}
+
+// SendBreak transmits a continuous stream of zero bits for the given duration,
+// if the terminal is using asynchronous serial data transmission.  If the
+// duration is zero, the break lasts between 0.25 and 0.5 seconds.  Otherwise,
+// its precision depends on the system (on Linux, it is rounded up to the
+// nearest tenth of a second).
+func (tio *TermSettings) SendBreak(duration time.Duration) error {
+	return tcsendbreak(tio.fd, duration)
 }
 
 /*
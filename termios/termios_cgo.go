@@ -0,0 +1,259 @@
+// +build darwin linux,mips linux,mipsle linux,mips64 linux,mips64le linux,ppc64 linux,ppc64le
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+/*
+#include <termios.h>
+#include <sys/ioctl.h>
+#include <unistd.h>
+*/
+import "C"
+
+type (
+	inMode    C.tcflag_t
+	outMode   C.tcflag_t
+	ctlMode   C.tcflag_t
+	locMode   C.tcflag_t
+	charIndex C.cc_t
+)
+
+// Input Flags
+const (
+	IGNBRK  inMode = C.IGNBRK  // ignore BREAK condition
+	BRKINT  inMode = C.BRKINT  // map BREAK to SIGINTR
+	IGNPAR  inMode = C.IGNPAR  // ignore (discard) parity errors
+	PARMRK  inMode = C.PARMRK  // mark parity and framing errors
+	INPCK   inMode = C.INPCK   // enable checking of parity errors
+	ISTRIP  inMode = C.ISTRIP  // strip 8th bit off chars
+	INLCR   inMode = C.INLCR   // map NL into CR
+	IGNCR   inMode = C.IGNCR   // ignore CR
+	ICRNL   inMode = C.ICRNL   // map CR to NL (ala CRMOD)
+	IXON    inMode = C.IXON    // enable output flow control
+	IXOFF   inMode = C.IXOFF   // enable input flow control
+	IXANY   inMode = C.IXANY   // any char will restart after stop
+	IMAXBEL inMode = C.IMAXBEL // ring bell on input queue full
+	IUTF8   inMode = C.IUTF8   // maintain state for UTF-8 VERASE
+)
+
+// Output Flags
+const (
+	OPOST  outMode = C.OPOST  // enable following output processing
+	ONLCR  outMode = C.ONLCR  // map NL to CR-NL (ala CRMOD)
+	OCRNL  outMode = C.OCRNL  // map CR to NL on output
+	ONOCR  outMode = C.ONOCR  // no CR output at column 0
+	ONLRET outMode = C.ONLRET // NL performs CR function
+	OFILL  outMode = C.OFILL  // use fill characters for delay
+	NLDLY  outMode = C.NLDLY  // \n delay
+	TABDLY outMode = C.TABDLY // horizontal tab delay
+	CRDLY  outMode = C.CRDLY  // \r delay
+	FFDLY  outMode = C.FFDLY  // form feed delay
+	BSDLY  outMode = C.BSDLY  // \b delay
+	VTDLY  outMode = C.VTDLY  // vertical tab delay
+	OFDEL  outMode = C.OFDEL  // fill is DEL, else NUL
+)
+
+// Control Flags
+const (
+	CSIZE  ctlMode = C.CSIZE  // character size mask
+	CS6    ctlMode = C.CS6    // 6 bits
+	CS7    ctlMode = C.CS7    // 7 bits
+	CS8    ctlMode = C.CS8    // 8 bits
+	CS5    ctlMode = C.CS5    // 5 bits
+	CSTOPB ctlMode = C.CSTOPB // send 2 stop bits
+	CREAD  ctlMode = C.CREAD  // enable receiver
+	PARENB ctlMode = C.PARENB // parity enable
+	PARODD ctlMode = C.PARODD // odd parity, else even
+	HUPCL  ctlMode = C.HUPCL  // hang up on last close
+	CLOCAL ctlMode = C.CLOCAL // ignore modem status lines
+
+	CRTSCTS ctlMode = C.CRTSCTS // RTS/CTS (hardware) flow control
+)
+
+// Local flags
+const (
+	ECHOKE  locMode = C.ECHOKE  // visual erase for line kill
+	ECHOE   locMode = C.ECHOE   // visually erase chars
+	ECHOK   locMode = C.ECHOK   // echo NL after line kill
+	ECHO    locMode = C.ECHO    // enable echoing
+	ECHONL  locMode = C.ECHONL  // echo NL even if ECHO is off
+	ECHOPRT locMode = C.ECHOPRT // visual erase mode for hardcopy
+	ECHOCTL locMode = C.ECHOCTL // echo control chars as ^(Char)
+	ISIG    locMode = C.ISIG    // enable signals INTR, QUIT, [D]SUSP
+	ICANON  locMode = C.ICANON  // canonicalize input lines
+	IEXTEN  locMode = C.IEXTEN  // enable DISCARD and LNEXT
+	EXTPROC locMode = C.EXTPROC // external processing
+	TOSTOP  locMode = C.TOSTOP  // stop background jobs from output
+	FLUSHO  locMode = C.FLUSHO  // output being flushed (state)
+	PENDIN  locMode = C.PENDIN  // XXX retype pending input (state)
+	NOFLSH  locMode = C.NOFLSH  // don't flush after interrupt
+)
+
+// Control Character Indices
+const (
+	VEOF     charIndex = C.VEOF     // ICANON
+	VEOL     charIndex = C.VEOL     // ICANON
+	VEOL2    charIndex = C.VEOL2    // ICANON together with IEXTEN
+	VERASE   charIndex = C.VERASE   // ICANON
+	VWERASE  charIndex = C.VWERASE  // ICANON together with IEXTEN
+	VKILL    charIndex = C.VKILL    // ICANON
+	VREPRINT charIndex = C.VREPRINT // ICANON together with IEXTEN
+	VINTR    charIndex = C.VINTR    // ISIG
+	VQUIT    charIndex = C.VQUIT    // ISIG
+	VSUSP    charIndex = C.VSUSP    // ISIG
+	VSTART   charIndex = C.VSTART   // IXON, IXOFF
+	VSTOP    charIndex = C.VSTOP    // IXON, IXOFF
+	VLNEXT   charIndex = C.VLNEXT   // IEXTEN
+	VDISCARD charIndex = C.VDISCARD // IEXTEN
+	VMIN     charIndex = C.VMIN     // !ICANON
+	VTIME    charIndex = C.VTIME    // !ICANON
+	NCC      charIndex = C.NCCS     // Number of control chars
+)
+
+// Queues for Flush
+const (
+	TCIFLUSH  queueSelector = C.TCIFLUSH  // discard data received but not read
+	TCOFLUSH  queueSelector = C.TCOFLUSH  // discard data written but not transmitted
+	TCIOFLUSH queueSelector = C.TCIOFLUSH // discard both
+)
+
+// Actions for Flow
+const (
+	TCOOFF flowAction = C.TCOOFF // suspend output
+	TCOON  flowAction = C.TCOON  // restart output
+	TCIOFF flowAction = C.TCIOFF // transmit a STOP character
+	TCION  flowAction = C.TCION  // transmit a START character
+)
+
+// Actions for tcsetattr
+const (
+	tcsanow   = C.TCSANOW   // apply the settings immediately
+	tcsadrain = C.TCSADRAIN // apply the settings after output has been written
+	tcsaflush = C.TCSAFLUSH // apply the settings after output has been written and discard input
+)
+
+// vdisable is the value of a disabled control character.
+const vdisable = C._POSIX_VDISABLE
+
+// The following use the C library.  The layout of syscall.Termios matches
+// struct termios on these platforms.
+
+func tcgetattr(fd int, t *syscall.Termios) error {
+	if ret, errno := C.tcgetattr(C.int(fd), (*C.struct_termios)(unsafe.Pointer(t))); ret != 0 {
+		return errno
+	}
+	return nil
+}
+
+func tcsetattr(fd int, when int, t *syscall.Termios) error {
+	if ret, errno := C.tcsetattr(C.int(fd), C.int(when), (*C.struct_termios)(unsafe.Pointer(t))); ret != 0 {
+		return errno
+	}
+	return nil
+}
+
+func cfmakeraw(t *syscall.Termios) {
+	C.cfmakeraw((*C.struct_termios)(unsafe.Pointer(t)))
+}
+
+func tcflush(fd int, queue int) error {
+	if ret, errno := C.tcflush(C.int(fd), C.int(queue)); ret != 0 {
+		return errno
+	}
+	return nil
+}
+
+func tcflow(fd int, action int) error {
+	if ret, errno := C.tcflow(C.int(fd), C.int(action)); ret != 0 {
+		return errno
+	}
+	return nil
+}
+
+func tcdrain(fd int) error {
+	if ret, errno := C.tcdrain(C.int(fd)); ret != 0 {
+		return errno
+	}
+	return nil
+}
+
+// tcsendbreak passes the duration in milliseconds, which is how the GNU C
+// library interprets it (other systems ignore it).
+func tcsendbreak(fd int, duration time.Duration) error {
+	if duration < 0 {
+		duration = 0
+	}
+	ms := (duration + time.Millisecond - 1) / time.Millisecond
+	if ret, errno := C.tcsendbreak(C.int(fd), C.int(ms)); ret != 0 {
+		return errno
+	}
+	return nil
+}
+
+// speeds maps baud rates to the standard speed codes.  On darwin, the code
+// for every speed is the rate itself, so any rate can be used.
+var speeds = map[int]C.speed_t{
+	0:      C.B0,
+	50:     C.B50,
+	75:     C.B75,
+	110:    C.B110,
+	134:    C.B134,
+	150:    C.B150,
+	200:    C.B200,
+	300:    C.B300,
+	600:    C.B600,
+	1200:   C.B1200,
+	1800:   C.B1800,
+	2400:   C.B2400,
+	4800:   C.B4800,
+	9600:   C.B9600,
+	19200:  C.B19200,
+	38400:  C.B38400,
+	57600:  C.B57600,
+	115200: C.B115200,
+	230400: C.B230400,
+}
+
+func cfsetspeed(t *syscall.Termios, baud int) error {
+	code, ok := speeds[baud]
+	if !ok {
+		if runtime.GOOS != "darwin" || baud < 0 {
+			return syscall.EINVAL
+		}
+		code = C.speed_t(baud)
+	}
+	if ret, errno := C.cfsetspeed((*C.struct_termios)(unsafe.Pointer(t)), code); ret != 0 {
+		return errno
+	}
+	return nil
+}
+
+func cfgetspeed(t *syscall.Termios) int {
+	code := C.cfgetospeed((*C.struct_termios)(unsafe.Pointer(t)))
+	for baud, c := range speeds {
+		if c == code {
+			return baud
+		}
+	}
+	return int(code)
+}
@@ -0,0 +1,26 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+// tcflag is the type of the flag fields of syscall.Termios.
+type tcflag = uint64
+
+// tiocmiwait is the ioctl which waits for the modem lines to change (which
+// is not supported).
+const tiocmiwait = 0
+
+// tiocgsid is the ioctl which returns the session of a terminal (which is
+// not supported).
+const tiocgsid = 0
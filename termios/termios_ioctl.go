@@ -0,0 +1,345 @@
+// +build linux,!mips,!mipsle,!mips64,!mips64le,!ppc64,!ppc64le
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+import (
+	"math"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// This is a pure Go implementation for Linux using the TCGETS and TCSETS
+// ioctls, so that cgo is not required.  The values are those of the generic
+// Linux ABI (asm-generic/termbits.h), which is used by most architectures;
+// the others use the C library (see termios_cgo.go).
+
+type (
+	inMode    tcflag
+	outMode   tcflag
+	ctlMode   tcflag
+	locMode   tcflag
+	charIndex uint8
+)
+
+// Input Flags
+const (
+	IGNBRK  inMode = 0000001 // ignore BREAK condition
+	BRKINT  inMode = 0000002 // map BREAK to SIGINTR
+	IGNPAR  inMode = 0000004 // ignore (discard) parity errors
+	PARMRK  inMode = 0000010 // mark parity and framing errors
+	INPCK   inMode = 0000020 // enable checking of parity errors
+	ISTRIP  inMode = 0000040 // strip 8th bit off chars
+	INLCR   inMode = 0000100 // map NL into CR
+	IGNCR   inMode = 0000200 // ignore CR
+	ICRNL   inMode = 0000400 // map CR to NL (ala CRMOD)
+	IXON    inMode = 0002000 // enable output flow control
+	IXOFF   inMode = 0010000 // enable input flow control
+	IXANY   inMode = 0004000 // any char will restart after stop
+	IMAXBEL inMode = 0020000 // ring bell on input queue full
+	IUTF8   inMode = 0040000 // maintain state for UTF-8 VERASE
+)
+
+// Output Flags
+const (
+	OPOST  outMode = 0000001 // enable following output processing
+	ONLCR  outMode = 0000004 // map NL to CR-NL (ala CRMOD)
+	OCRNL  outMode = 0000010 // map CR to NL on output
+	ONOCR  outMode = 0000020 // no CR output at column 0
+	ONLRET outMode = 0000040 // NL performs CR function
+	OFILL  outMode = 0000100 // use fill characters for delay
+	NLDLY  outMode = 0000400 // \n delay
+	TABDLY outMode = 0014000 // horizontal tab delay
+	CRDLY  outMode = 0003000 // \r delay
+	FFDLY  outMode = 0100000 // form feed delay
+	BSDLY  outMode = 0020000 // \b delay
+	VTDLY  outMode = 0040000 // vertical tab delay
+	OFDEL  outMode = 0000200 // fill is DEL, else NUL
+)
+
+// Control Flags
+const (
+	CSIZE  ctlMode = 0000060 // character size mask
+	CS6    ctlMode = 0000020 // 6 bits
+	CS7    ctlMode = 0000040 // 7 bits
+	CS8    ctlMode = 0000060 // 8 bits
+	CS5    ctlMode = 0000000 // 5 bits
+	CSTOPB ctlMode = 0000100 // send 2 stop bits
+	CREAD  ctlMode = 0000200 // enable receiver
+	PARENB ctlMode = 0000400 // parity enable
+	PARODD ctlMode = 0001000 // odd parity, else even
+	HUPCL  ctlMode = 0002000 // hang up on last close
+	CLOCAL ctlMode = 0004000 // ignore modem status lines
+
+	CRTSCTS ctlMode = 020000000000 // RTS/CTS (hardware) flow control
+)
+
+// Local flags
+const (
+	ECHOKE  locMode = 0004000 // visual erase for line kill
+	ECHOE   locMode = 0000020 // visually erase chars
+	ECHOK   locMode = 0000040 // echo NL after line kill
+	ECHO    locMode = 0000010 // enable echoing
+	ECHONL  locMode = 0000100 // echo NL even if ECHO is off
+	ECHOPRT locMode = 0002000 // visual erase mode for hardcopy
+	ECHOCTL locMode = 0001000 // echo control chars as ^(Char)
+	ISIG    locMode = 0000001 // enable signals INTR, QUIT, [D]SUSP
+	ICANON  locMode = 0000002 // canonicalize input lines
+	IEXTEN  locMode = 0100000 // enable DISCARD and LNEXT
+	EXTPROC locMode = 0200000 // external processing
+	TOSTOP  locMode = 0000400 // stop background jobs from output
+	FLUSHO  locMode = 0010000 // output being flushed (state)
+	PENDIN  locMode = 0040000 // XXX retype pending input (state)
+	NOFLSH  locMode = 0000200 // don't flush after interrupt
+)
+
+// Control Character Indices
+const (
+	VEOF     charIndex = 4  // ICANON
+	VEOL     charIndex = 11 // ICANON
+	VEOL2    charIndex = 16 // ICANON together with IEXTEN
+	VERASE   charIndex = 2  // ICANON
+	VWERASE  charIndex = 14 // ICANON together with IEXTEN
+	VKILL    charIndex = 3  // ICANON
+	VREPRINT charIndex = 12 // ICANON together with IEXTEN
+	VINTR    charIndex = 0  // ISIG
+	VQUIT    charIndex = 1  // ISIG
+	VSUSP    charIndex = 10 // ISIG
+	VSTART   charIndex = 8  // IXON, IXOFF
+	VSTOP    charIndex = 9  // IXON, IXOFF
+	VLNEXT   charIndex = 15 // IEXTEN
+	VDISCARD charIndex = 13 // IEXTEN
+	VMIN     charIndex = 6  // !ICANON
+	VTIME    charIndex = 5  // !ICANON
+	NCC      charIndex = 32 // Number of control chars
+)
+
+// vdisable is the value of a disabled control character.
+const vdisable = 0
+
+// Terminal ioctls
+const (
+	tcgets  = 0x5401
+	tcsets  = 0x5402
+	tcsetsw = 0x5403
+	tcsetsf = 0x5404
+	tcsbrk  = 0x5409
+	tcxonc  = 0x540A
+	tcflsh  = 0x540B
+	tcsbrkp = 0x5425
+
+	tcgets2  = 0x802C542A
+	tcsets2  = 0x402C542B
+	tcsetsw2 = 0x402C542C
+	tcsetsf2 = 0x402C542D
+)
+
+// Speeds (in c_cflag)
+const (
+	cbaud   = 0010017      // mask for the output speed
+	cibaud  = 002003600000 // mask for the input speed
+	bother  = 0010000      // the speed is given in c_ospeed (termios2 only)
+	ibshift = 16           // shift from cbaud to cibaud
+)
+
+// speeds maps the standard speed codes to their baud rates.
+var speeds = map[tcflag]int{
+	0000000: 0,
+	0000001: 50,
+	0000002: 75,
+	0000003: 110,
+	0000004: 134,
+	0000005: 150,
+	0000006: 200,
+	0000007: 300,
+	0000010: 600,
+	0000011: 1200,
+	0000012: 1800,
+	0000013: 2400,
+	0000014: 4800,
+	0000015: 9600,
+	0000016: 19200,
+	0000017: 38400,
+	0010001: 57600,
+	0010002: 115200,
+	0010003: 230400,
+	0010004: 460800,
+	0010005: 500000,
+	0010006: 576000,
+	0010007: 921600,
+	0010010: 1000000,
+	0010011: 1152000,
+	0010012: 1500000,
+	0010013: 2000000,
+	0010014: 2500000,
+	0010015: 3000000,
+	0010016: 3500000,
+	0010017: 4000000,
+}
+
+// termios2 is the layout of struct termios2 used by TCGETS2 and TCSETS2, which
+// (unlike struct termios) can hold arbitrary speeds.
+type termios2 struct {
+	iflag, oflag, cflag, lflag tcflag
+	line                       uint8
+	cc                         [19]uint8
+	ispeed, ospeed             uint32
+}
+
+// Queues for Flush
+const (
+	TCIFLUSH  queueSelector = 0 // discard data received but not read
+	TCOFLUSH  queueSelector = 1 // discard data written but not transmitted
+	TCIOFLUSH queueSelector = 2 // discard both
+)
+
+// Actions for Flow
+const (
+	TCOOFF flowAction = 0 // suspend output
+	TCOON  flowAction = 1 // restart output
+	TCIOFF flowAction = 2 // transmit a STOP character
+	TCION  flowAction = 3 // transmit a START character
+)
+
+// Actions for tcsetattr
+const (
+	tcsanow   = 0 // apply the settings immediately
+	tcsadrain = 1 // apply the settings after output has been written
+	tcsaflush = 2 // apply the settings after output has been written and discard input
+)
+
+// tcgetattr reads the settings of the terminal into t.  As in the GNU C
+// library, the speeds are stored in t.Ispeed and t.Ospeed; they are read with
+// TCGETS2 if the kernel supports it.
+func tcgetattr(fd int, t *syscall.Termios) error {
+	var t2 termios2
+	if err := ioctl(fd, tcgets2, unsafe.Pointer(&t2)); err != nil {
+		if err := ioctl(fd, tcgets, unsafe.Pointer(t)); err != nil {
+			return err
+		}
+		t.Ospeed = uint32(speeds[t.Cflag&cbaud])
+		t.Ispeed = t.Ospeed
+		if in := t.Cflag & cibaud >> ibshift; in != 0 {
+			t.Ispeed = uint32(speeds[in])
+		}
+		return nil
+	}
+	t.Iflag, t.Oflag, t.Cflag, t.Lflag = t2.iflag, t2.oflag, t2.cflag, t2.lflag
+	t.Line = t2.line
+	copy(t.Cc[:], t2.cc[:])
+	t.Ispeed, t.Ospeed = t2.ispeed, t2.ospeed
+	return nil
+}
+
+// tcsetattr applies the settings in t to the terminal.  The action must be
+// one of tcsanow, tcsadrain, or tcsaflush.
+func tcsetattr(fd int, when int, t *syscall.Termios) error {
+	var req uintptr
+	switch when {
+	case tcsanow:
+		req = tcsets
+	case tcsadrain:
+		req = tcsetsw
+	case tcsaflush:
+		req = tcsetsf
+	default:
+		return syscall.EINVAL
+	}
+	if t.Cflag&cbaud == bother || t.Cflag&cibaud == bother<<ibshift {
+		return tcsetattr2(fd, req, t)
+	}
+	return ioctl(fd, req, unsafe.Pointer(t))
+}
+
+// tcsetattr2 applies the settings in t (which have a non-standard speed) to
+// the terminal with the termios2 version of the given TCSETS ioctl.
+func tcsetattr2(fd int, req uintptr, t *syscall.Termios) error {
+	switch req {
+	case tcsets:
+		req = tcsets2
+	case tcsetsw:
+		req = tcsetsw2
+	case tcsetsf:
+		req = tcsetsf2
+	}
+	t2 := termios2{
+		iflag:  t.Iflag,
+		oflag:  t.Oflag,
+		cflag:  t.Cflag,
+		lflag:  t.Lflag,
+		line:   t.Line,
+		ispeed: t.Ispeed,
+		ospeed: t.Ospeed,
+	}
+	copy(t2.cc[:], t.Cc[:])
+	return ioctl(fd, req, unsafe.Pointer(&t2))
+}
+
+// cfsetspeed sets both the input and output speed of t to the given baud
+// rate.  Standard rates use the speed codes in c_cflag; others use BOTHER.
+func cfsetspeed(t *syscall.Termios, baud int) error {
+	if baud < 0 || int64(baud) > math.MaxUint32 {
+		return syscall.EINVAL
+	}
+	code := tcflag(bother)
+	for c, b := range speeds {
+		if b == baud {
+			code = c
+			break
+		}
+	}
+	t.Cflag &^= cbaud | cibaud
+	t.Cflag |= code
+	t.Ispeed, t.Ospeed = uint32(baud), uint32(baud)
+	return nil
+}
+
+// cfgetspeed returns the output speed of t.
+func cfgetspeed(t *syscall.Termios) int {
+	if t.Cflag&cbaud == bother {
+		return int(t.Ospeed)
+	}
+	return speeds[t.Cflag&cbaud]
+}
+
+func tcflush(fd int, queue int) error { return ioctlValue(fd, tcflsh, queue) }
+func tcflow(fd int, action int) error { return ioctlValue(fd, tcxonc, action) }
+func tcdrain(fd int) error            { return ioctlValue(fd, tcsbrk, 1) }
+
+// tcsendbreak sends a break as tcsendbreak(3) does in the GNU C library: a
+// zero duration uses TCSBRK, and other durations are given to TCSBRKP in
+// tenths of a second (rounded up).
+func tcsendbreak(fd int, duration time.Duration) error {
+	if duration <= 0 {
+		return ioctlValue(fd, tcsbrk, 0)
+	}
+	tenths := (duration + time.Second/10 - 1) / (time.Second / 10)
+	return ioctlValue(fd, tcsbrkp, int(tenths))
+}
+
+// cfmakeraw sets t to raw mode exactly as cfmakeraw(3) does in the GNU C
+// library: input is available character by character, echoing is disabled,
+// and all special processing of input and output characters is disabled.
+func cfmakeraw(t *syscall.Termios) {
+	t.Iflag &^= tcflag(IGNBRK | BRKINT | PARMRK | ISTRIP | INLCR | IGNCR | ICRNL | IXON)
+	t.Oflag &^= tcflag(OPOST)
+	t.Lflag &^= tcflag(ECHO | ECHONL | ICANON | ISIG | IEXTEN)
+	t.Cflag &^= tcflag(CSIZE | PARENB)
+	t.Cflag |= tcflag(CS8)
+	t.Cc[VMIN] = 1
+	t.Cc[VTIME] = 0
+}
@@ -0,0 +1,53 @@
+// +build linux,!mips,!mipsle,!mips64,!mips64le,!ppc64,!ppc64le
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+import (
+	"syscall"
+	"testing"
+)
+
+// The expected values are the results of cfmakeraw(3) from the GNU C library.
+var cfmakerawTests = []struct {
+	Desc  string
+	Start syscall.Termios
+	Raw   syscall.Termios
+}{
+	{
+		Desc:  "cooked",
+		Start: syscall.Termios{Iflag: 0x2B02, Oflag: 0x3, Cflag: 0x4B00, Lflag: 0x200005CB},
+		Raw:   syscall.Termios{Iflag: 0x2A00, Oflag: 0x2, Cflag: 0x4A30, Lflag: 0x20000580},
+	},
+	{
+		Desc:  "all flags",
+		Start: syscall.Termios{Iflag: 0xFFFFFFFF, Oflag: 0xFFFFFFFF, Cflag: 0xFFFFFFFF, Lflag: 0xFFFFFFFF},
+		Raw:   syscall.Termios{Iflag: 0xFFFFFA14, Oflag: 0xFFFFFFFE, Cflag: 0xFFFFFEFF, Lflag: 0xFFFF7FB4},
+	},
+}
+
+func TestCfmakeraw(t *testing.T) {
+	for _, test := range cfmakerawTests {
+		test.Start.Cc[VMIN], test.Start.Cc[VTIME] = 7, 9
+		test.Raw.Cc[VMIN], test.Raw.Cc[VTIME] = 1, 0
+
+		got := test.Start
+		cfmakeraw(&got)
+		if got != test.Raw {
+			t.Errorf("%s: cfmakeraw(%+v) = %+v, want %+v", test.Desc, test.Start, got, test.Raw)
+		}
+	}
+}
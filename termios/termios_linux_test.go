@@ -0,0 +1,180 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// openPty opens a new pseudoterminal, returning the master and slave.
+func openPty(t *testing.T) (master, slave *os.File) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no ptys: %s", err)
+	}
+	var unlock int32
+	if err := ioctl(int(master.Fd()), syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		t.Fatalf("TIOCSPTLCK: %s", err)
+	}
+	var n uint32
+	if err := ioctl(int(master.Fd()), syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		t.Fatalf("TIOCGPTN: %s", err)
+	}
+	slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatalf("open slave: %s", err)
+	}
+	return master, slave
+}
+
+func TestSetSize(t *testing.T) {
+	master, slave := openPty(t)
+	defer master.Close()
+	defer slave.Close()
+
+	tio, err := NewTermSettings(int(master.Fd()))
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+	if err := tio.SetSize(132, 43, 1320, 860); err != nil {
+		t.Fatalf("SetSize: %s", err)
+	}
+
+	tio, err = NewTermSettings(int(slave.Fd()))
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+	w, h, xp, yp, err := tio.GetSizePixels()
+	if err != nil {
+		t.Fatalf("GetSizePixels: %s", err)
+	}
+	if got, want := [4]int{w, h, xp, yp}, [4]int{132, 43, 1320, 860}; got != want {
+		t.Errorf("GetSizePixels() = %v, want %v", got, want)
+	}
+	if w, h, err := tio.GetSize(); err != nil || w != 132 || h != 43 {
+		t.Errorf("GetSize() = %d, %d, %v; want 132, 43", w, h, err)
+	}
+}
+
+func TestName(t *testing.T) {
+	master, slave := openPty(t)
+	defer master.Close()
+	defer slave.Close()
+
+	if !IsTerminal(int(slave.Fd())) {
+		t.Errorf("IsTerminal(slave) = false")
+	}
+	if got, err := Name(int(slave.Fd())); err != nil || got != slave.Name() {
+		t.Errorf("Name(slave) = %q, %v; want %q", got, err, slave.Name())
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %s", err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if IsTerminal(int(r.Fd())) {
+		t.Errorf("IsTerminal(pipe) = true")
+	}
+	if got, err := Name(int(r.Fd())); err == nil {
+		t.Errorf("Name(pipe) = %q, want error", got)
+	}
+}
+
+func TestForegroundGroup(t *testing.T) {
+	master, slave := openPty(t)
+	defer master.Close()
+	defer slave.Close()
+
+	// Start a session leader with the slave as its controlling terminal
+	cmd := exec.Command("sleep", "10")
+	cmd.Stdin = slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Start: %s", err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	tio, err := NewTermSettings(int(master.Fd()))
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+	pid := cmd.Process.Pid
+
+	// The child's session may not have the terminal yet
+	var pgrp, sid int
+	for i := 0; i < 100; i++ {
+		if pgrp, err = tio.ForegroundGroup(); err == nil && pgrp == pid {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil || pgrp != pid {
+		t.Errorf("ForegroundGroup() = %d, %v; want %d", pgrp, err, pid)
+	}
+	if sid, err = tio.Session(); err != nil || sid != pid {
+		t.Errorf("Session() = %d, %v; want %d", sid, err, pid)
+	}
+
+	// This process is not in the session, so it cannot change the foreground
+	// group or detach from the terminal
+	if err := tio.SetForegroundGroup(syscall.Getpgrp()); err == nil {
+		t.Errorf("SetForegroundGroup from outside the session succeeded")
+	}
+	if err := tio.Detach(); err == nil {
+		t.Errorf("Detach from a terminal which is not controlling succeeded")
+	}
+}
+
+func TestWatchSize(t *testing.T) {
+	master, slave := openPty(t)
+	defer master.Close()
+	defer slave.Close()
+
+	tio, err := NewTermSettings(int(slave.Fd()))
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+
+	sizes := make(chan [2]int, 1)
+	stop := tio.WatchSize(func(w, h int) {
+		select {
+		case sizes <- [2]int{w, h}:
+		default:
+		}
+	})
+	defer stop()
+
+	if err := tio.SetSize(34, 12, 0, 0); err != nil {
+		t.Fatalf("SetSize: %s", err)
+	}
+	syscall.Kill(os.Getpid(), syscall.SIGWINCH)
+	select {
+	case got := <-sizes:
+		if want := [2]int{34, 12}; got != want {
+			t.Errorf("size = %v, want %v", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("timed out waiting for resize")
+	}
+}
@@ -19,6 +19,7 @@ package termios
 
 import (
 	"testing"
+	"time"
 )
 
 func TestTermSettings(t *testing.T) {
@@ -46,3 +47,160 @@ func TestTermSize(t *testing.T) {
 	}
 	t.Logf("Size: %d cols, %d rows", w, h)
 }
+
+func TestFlags(t *testing.T) {
+	tio := new(TermSettings)
+	tio.SetInput(ICRNL | IXON)
+	tio.SetLocal(ECHO | ICANON | ISIG)
+	tio.SetControl(CS8 | CREAD)
+
+	tio.Disable(ECHO, IXON)
+	tio.Enable(OPOST, ONLCR, IEXTEN)
+
+	if got, want := tio.Input(), ICRNL; got != want {
+		t.Errorf("Input() = %#o, want %#o", got, want)
+	}
+	if got, want := tio.Output(), OPOST|ONLCR; got != want {
+		t.Errorf("Output() = %#o, want %#o", got, want)
+	}
+	if got, want := tio.Local(), ICANON|ISIG|IEXTEN; got != want {
+		t.Errorf("Local() = %#o, want %#o", got, want)
+	}
+	if got, want := tio.Control()&CSIZE, CS8; got != want {
+		t.Errorf("Control()&CSIZE = %#o, want %#o", got, want)
+	}
+
+	for _, test := range []struct {
+		Flag flag
+		Has  bool
+	}{
+		{ECHO, false},
+		{ISIG, true},
+		{ICANON | ISIG, true},
+		{ICANON | ECHO, false},
+		{ICRNL, true},
+		{IXON, false},
+		{OPOST, true},
+		{CREAD, true},
+		{CLOCAL, false},
+	} {
+		if got, want := tio.Has(test.Flag), test.Has; got != want {
+			t.Errorf("Has(%#o) = %v, want %v", test.Flag, got, want)
+		}
+	}
+}
+
+func TestChars(t *testing.T) {
+	tio := new(TermSettings)
+	tio.SetChar(VINTR, 3)
+	tio.SetChar(VQUIT, 28)
+	tio.DisableChar(VQUIT)
+	if got, want := tio.Char(VINTR), rune(3); got != want {
+		t.Errorf("Char(VINTR) = %d, want %d", got, want)
+	}
+	if got, want := tio.Char(VQUIT), Disabled; got != want {
+		t.Errorf("Char(VQUIT) = %d, want %d", got, want)
+	}
+
+	for _, test := range []struct {
+		Timeout time.Duration
+		Tenths  uint8
+		Read    time.Duration
+	}{
+		{0, 0, 0},
+		{100 * time.Millisecond, 1, 100 * time.Millisecond},
+		{150 * time.Millisecond, 2, 200 * time.Millisecond},
+		{time.Minute, 255, 25500 * time.Millisecond},
+		{-time.Second, 0, 0},
+	} {
+		tio.SetReadTimeout(test.Timeout)
+		if got, want := tio.current.Cc[VTIME], test.Tenths; got != want {
+			t.Errorf("SetReadTimeout(%v): VTIME = %d, want %d", test.Timeout, got, want)
+		}
+		if got, want := tio.ReadTimeout(), test.Read; got != want {
+			t.Errorf("SetReadTimeout(%v): ReadTimeout() = %v, want %v", test.Timeout, got, want)
+		}
+	}
+
+	tio.SetMinRead(4)
+	if got, want := tio.MinRead(), 4; got != want {
+		t.Errorf("MinRead() = %d, want %d", got, want)
+	}
+}
+
+func TestQueues(t *testing.T) {
+	tio, err := NewTermSettings(0)
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+	defer tio.Reset()
+
+	for _, test := range []struct {
+		Desc string
+		Func func() error
+	}{
+		{"ApplyDrain", tio.ApplyDrain},
+		{"ApplyFlush", tio.ApplyFlush},
+		{"Flush(TCIFLUSH)", func() error { return tio.Flush(TCIFLUSH) }},
+		{"Flush(TCIOFLUSH)", func() error { return tio.Flush(TCIOFLUSH) }},
+		{"Drain", tio.Drain},
+		{"Flow(TCOON)", func() error { return tio.Flow(TCOON) }},
+		{"SendBreak(0)", func() error { return tio.SendBreak(0) }},
+		{"SendBreak(100ms)", func() error { return tio.SendBreak(100 * time.Millisecond) }},
+	} {
+		if err := test.Func(); err != nil {
+			t.Errorf("%s: %s", test.Desc, err)
+		}
+	}
+}
+
+func TestGuard(t *testing.T) {
+	tio, err := NewTermSettings(0)
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+	guard := tio.Guard()
+	defer guard.Close()
+
+	if err := tio.Raw(); err != nil {
+		t.Fatalf("Raw: %s", err)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recover() = %v, want %q", r, "boom")
+			}
+		}()
+		defer guard.Recover()
+		panic("boom")
+	}()
+
+	restored, err := NewTermSettings(0)
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+	if got, want := restored.current, tio.original; got != want {
+		t.Errorf("after Recover, settings = %v, want %v", got, want)
+	}
+
+	// Only the first call restores the settings
+	tio.Raw()
+	if err := guard.Close(); err != nil {
+		t.Errorf("Close: %s", err)
+	}
+	if err := tio.Reset(); err != nil {
+		t.Errorf("Reset: %s", err)
+	}
+}
+
+func TestOpenControlling(t *testing.T) {
+	tty, err := OpenControlling()
+	if err != nil {
+		t.Skipf("no controlling terminal: %s", err)
+	}
+	defer tty.Close()
+	if !IsTerminal(int(tty.Fd())) {
+		t.Errorf("IsTerminal(%s) = false", tty.Name())
+	}
+}
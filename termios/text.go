@@ -0,0 +1,160 @@
+// +build linux darwin
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A flagName is the name of a single flag.
+type flagName struct {
+	flag flag
+	name string
+}
+
+// The names of the flags, in the order they are listed by String.
+var (
+	inputNames = []flagName{
+		{IGNBRK, "IGNBRK"}, {BRKINT, "BRKINT"}, {IGNPAR, "IGNPAR"}, {PARMRK, "PARMRK"},
+		{INPCK, "INPCK"}, {ISTRIP, "ISTRIP"}, {INLCR, "INLCR"}, {IGNCR, "IGNCR"},
+		{ICRNL, "ICRNL"}, {IXON, "IXON"}, {IXOFF, "IXOFF"}, {IXANY, "IXANY"},
+		{IMAXBEL, "IMAXBEL"}, {IUTF8, "IUTF8"},
+	}
+	outputNames = []flagName{
+		{OPOST, "OPOST"}, {ONLCR, "ONLCR"}, {OCRNL, "OCRNL"}, {ONOCR, "ONOCR"},
+		{ONLRET, "ONLRET"}, {OFILL, "OFILL"}, {OFDEL, "OFDEL"},
+	}
+	controlNames = []flagName{
+		{CSTOPB, "CSTOPB"}, {CREAD, "CREAD"}, {PARENB, "PARENB"}, {PARODD, "PARODD"},
+		{HUPCL, "HUPCL"}, {CLOCAL, "CLOCAL"}, {CRTSCTS, "CRTSCTS"},
+	}
+	localNames = []flagName{
+		{ISIG, "ISIG"}, {ICANON, "ICANON"}, {IEXTEN, "IEXTEN"}, {ECHO, "ECHO"},
+		{ECHOE, "ECHOE"}, {ECHOK, "ECHOK"}, {ECHONL, "ECHONL"}, {NOFLSH, "NOFLSH"},
+		{TOSTOP, "TOSTOP"}, {ECHOPRT, "ECHOPRT"}, {ECHOCTL, "ECHOCTL"}, {ECHOKE, "ECHOKE"},
+		{FLUSHO, "FLUSHO"}, {PENDIN, "PENDIN"}, {EXTPROC, "EXTPROC"},
+	}
+	sizeNames = map[ctlMode]string{CS5: "CS5", CS6: "CS6", CS7: "CS7", CS8: "CS8"}
+)
+
+// charNames are the names (as used by stty) of the control characters, in the
+// order they are listed by String.
+var charNames = []struct {
+	idx  charIndex
+	name string
+}{
+	{VINTR, "intr"}, {VQUIT, "quit"}, {VERASE, "erase"}, {VKILL, "kill"},
+	{VEOF, "eof"}, {VEOL, "eol"}, {VEOL2, "eol2"}, {VSTART, "start"},
+	{VSTOP, "stop"}, {VSUSP, "susp"}, {VREPRINT, "rprnt"}, {VWERASE, "werase"},
+	{VLNEXT, "lnext"}, {VDISCARD, "discard"},
+}
+
+// flagString returns the names of the given flags, each preceded by a - if it
+// is not set in the flag word (in the style of stty -a).
+func flagString(names []flagName, word tcflag) string {
+	list := make([]string, 0, len(names))
+	for _, n := range names {
+		if word&n.flag.mask() == n.flag.mask() {
+			list = append(list, n.name)
+		} else {
+			list = append(list, "-"+n.name)
+		}
+	}
+	return strings.Join(list, " ")
+}
+
+// charString returns the readable form of a control character: ^C for control
+// characters, ^? for DEL, M- followed by the 7-bit form for characters with
+// the high bit set, and <undef> for disabled characters.
+func charString(ch rune) string {
+	switch {
+	case ch == Disabled:
+		return "<undef>"
+	case ch >= 0x80:
+		return "M-" + charString(ch&0x7F)
+	case ch < 0x20:
+		return "^" + string(ch+'@')
+	case ch == 0x7F:
+		return "^?"
+	}
+	return string(ch)
+}
+
+// charsString returns the control characters of the current settings in the
+// style of stty -a.
+func (tio *TermSettings) charsString() string {
+	list := make([]string, 0, len(charNames)+2)
+	for _, c := range charNames {
+		list = append(list, c.name+"="+charString(tio.Char(c.idx)))
+	}
+	list = append(list, "min="+strconv.Itoa(tio.MinRead()))
+	list = append(list, fmt.Sprintf("time=%d", tio.current.Cc[VTIME]))
+	return strings.Join(list, " ")
+}
+
+// MarshalText encodes the current settings in the format printed by GNU
+// "stty -g": the input, output, control and local flags followed by each of
+// the control characters, all in hexadecimal and separated by colons.  The
+// settings can be restored with UnmarshalText, or by passing the text to stty.
+func (tio *TermSettings) MarshalText() ([]byte, error) {
+	t := &tio.current
+	fields := make([]string, 0, 4+len(t.Cc))
+	for _, word := range []tcflag{t.Iflag, t.Oflag, t.Cflag, t.Lflag} {
+		fields = append(fields, strconv.FormatUint(uint64(word), 16))
+	}
+	for _, ch := range t.Cc {
+		fields = append(fields, strconv.FormatUint(uint64(ch), 16))
+	}
+	return []byte(strings.Join(fields, ":")), nil
+}
+
+// UnmarshalText decodes settings in the format produced by MarshalText (and
+// GNU "stty -g") into the current settings.  The settings are not applied
+// until Apply is called; for instance, the following restores saved settings
+// to standard input:
+//   tio, err := termios.NewTermSettings(0)
+//   if err != nil { ... }
+//   if err := tio.UnmarshalText(saved); err != nil { ... }
+//   if err := tio.Apply(); err != nil { ... }
+func (tio *TermSettings) UnmarshalText(text []byte) error {
+	fields := strings.Split(strings.TrimSpace(string(text)), ":")
+	t := tio.current
+	if len(fields) != 4+len(t.Cc) {
+		return fmt.Errorf("termios: invalid settings %q: %d fields, want %d", text, len(fields), 4+len(t.Cc))
+	}
+	for i, word := range []*tcflag{&t.Iflag, &t.Oflag, &t.Cflag, &t.Lflag} {
+		v, err := strconv.ParseUint(fields[i], 16, 64)
+		if err == nil && uint64(tcflag(v)) != v {
+			err = fmt.Errorf("flags %s out of range", fields[i])
+		}
+		if err != nil {
+			return fmt.Errorf("termios: invalid settings %q: %s", text, err)
+		}
+		*word = tcflag(v)
+	}
+	for i := range t.Cc {
+		v, err := strconv.ParseUint(fields[4+i], 16, 8)
+		if err != nil {
+			return fmt.Errorf("termios: invalid settings %q: %s", text, err)
+		}
+		t.Cc[i] = uint8(v)
+	}
+	tio.current = t
+	return nil
+}
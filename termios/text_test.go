@@ -0,0 +1,115 @@
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Run this test with:
+//   go test -c && ./termios.test
+
+package termios
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCharString(t *testing.T) {
+	for _, test := range []struct {
+		Char rune
+		Want string
+	}{
+		{3, "^C"},
+		{28, "^\\"},
+		{0x7F, "^?"},
+		{'x', "x"},
+		{0x83, "M-^C"},
+		{Disabled, "<undef>"},
+	} {
+		if got := charString(test.Char); got != test.Want {
+			t.Errorf("charString(%#x) = %q, want %q", test.Char, got, test.Want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tio := new(TermSettings)
+	tio.SetInput(ICRNL | IXON)
+	tio.SetControl(CS7 | CREAD)
+	tio.SetLocal(ISIG | ICANON | ECHO)
+	tio.SetChar(VINTR, 3)
+	tio.SetChar(VERASE, 0x7F)
+
+	str := tio.String()
+	for _, want := range []string{
+		"Input   = -IGNBRK ", " ICRNL IXON -IXOFF ",
+		"Output  = -OPOST ",
+		"Control = CS7 -CSTOPB CREAD -PARENB ",
+		"Local   = ISIG ICANON -IEXTEN ECHO ",
+		"Chars   = intr=^C quit=<undef> erase=^? ",
+		" min=0 time=0\n",
+	} {
+		if !strings.Contains(str, want) {
+			t.Errorf("String() does not contain %q:\n%s", want, str)
+		}
+	}
+}
+
+func TestMarshalText(t *testing.T) {
+	tio, err := NewTermSettings(0)
+	if err != nil {
+		t.Fatalf("NewTermSettings: %s", err)
+	}
+	defer tio.Reset()
+	tio.Disable(ECHO, ICRNL)
+	tio.SetChar(VQUIT, 'q'&0x1F)
+	if err := tio.Apply(); err != nil {
+		t.Fatalf("Apply: %s", err)
+	}
+
+	text, err := tio.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText: %s", err)
+	}
+
+	// Compare with stty, if it uses the same format
+	cmd := exec.Command("stty", "-g")
+	cmd.Stdin = os.Stdin
+	if out, err := cmd.Output(); err != nil {
+		t.Logf("stty -g: %s", err)
+	} else if got, want := string(text), strings.TrimSpace(string(out)); got != want && strings.Count(want, ":") == strings.Count(got, ":") {
+		t.Errorf("MarshalText() = %q, stty -g = %q", got, want)
+	}
+
+	decoded := new(TermSettings)
+	if err := decoded.UnmarshalText(text); err != nil {
+		t.Fatalf("UnmarshalText(%q): %s", text, err)
+	}
+	if got, want := decoded.current, tio.current; got.Iflag != want.Iflag ||
+		got.Oflag != want.Oflag || got.Cflag != want.Cflag || got.Lflag != want.Lflag ||
+		got.Cc != want.Cc {
+		t.Errorf("UnmarshalText(%q) = %+v, want %+v", text, got, want)
+	}
+
+	for _, bad := range []string{
+		"",
+		"500:5:bf:8a3b",
+		strings.Replace(string(text), ":", ":x", 1),
+		"1" + string(text[strings.Index(string(text), ":"):]) + ":0",
+		"12345678901234567" + string(text[strings.Index(string(text), ":"):]),
+	} {
+		if err := decoded.UnmarshalText([]byte(bad)); err == nil {
+			t.Errorf("UnmarshalText(%q) succeeded", bad)
+		}
+	}
+}
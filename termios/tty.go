@@ -0,0 +1,82 @@
+// +build linux darwin
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// IsTerminal returns true if fd refers to a terminal.
+func IsTerminal(fd int) bool {
+	var t syscall.Termios
+	return tcgetattr(fd, &t) == nil
+}
+
+// errNotTerminal is returned by Name when fd is not a terminal.
+var errNotTerminal = errors.New("termios: not a terminal")
+
+// Name returns the path of the terminal device to which fd refers (like
+// ttyname), such as "/dev/pts/3".
+func Name(fd int) (string, error) {
+	if !IsTerminal(fd) {
+		return "", errNotTerminal
+	}
+	var st syscall.Stat_t
+	if err := syscall.Fstat(fd, &st); err != nil {
+		return "", err
+	}
+
+	// isDevice returns true if path is the device of fd
+	isDevice := func(path string) bool {
+		var dev syscall.Stat_t
+		if err := syscall.Stat(path, &dev); err != nil {
+			return false
+		}
+		return dev.Mode&syscall.S_IFMT == syscall.S_IFCHR && dev.Rdev == st.Rdev
+	}
+
+	// On Linux, the kernel knows the name of the file
+	if path, err := os.Readlink(fmt.Sprintf("/proc/self/fd/%d", fd)); err == nil && isDevice(path) {
+		return path, nil
+	}
+
+	// Otherwise, search the usual places for the device
+	for _, dir := range []string{"/dev/pts", "/dev"} {
+		names, err := filepath.Glob(filepath.Join(dir, "*"))
+		if err != nil {
+			continue
+		}
+		for _, path := range names {
+			if isDevice(path) {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("termios: no device found for fd %d", fd)
+}
+
+// OpenControlling opens the controlling terminal of the process (/dev/tty).
+// This allows a program to interact with the user even when its standard
+// input or output is redirected.  It returns an error if the process has no
+// controlling terminal.
+func OpenControlling() (*os.File, error) {
+	return os.OpenFile("/dev/tty", os.O_RDWR|syscall.O_CLOEXEC, 0)
+}
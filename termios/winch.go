@@ -0,0 +1,64 @@
+// +build linux darwin
+
+// Copyright 2013 Google, Inc.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termios
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// WatchSize calls fn with the size of the terminal whenever the terminal is
+// resized (on SIGWINCH).  It is called from a separate goroutine.  Sizes which
+// cannot be determined (or are zero) are not reported.  The returned function
+// stops watching; fn will not be called after it returns.
+//
+// WatchSize is intended to be used with the Resize method of a term.TTY:
+//   stop := tio.WatchSize(tty.Resize)
+//   defer stop()
+func (tio *TermSettings) WatchSize(fn func(width, height int)) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	quit := make(chan bool)
+	done := make(chan bool)
+	signal.Notify(sigs, syscall.SIGWINCH)
+
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-sigs:
+			case <-quit:
+				return
+			}
+			width, height, err := tio.GetSize()
+			if err != nil || width == 0 || height == 0 {
+				continue
+			}
+			fn(width, height)
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigs)
+		select {
+		case <-quit:
+		default:
+			close(quit)
+		}
+		<-done
+	}
+}